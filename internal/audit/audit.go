@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package audit records the source files read by the Go toolchain during a
+// build. It works by running this program as the -toolexec wrapper for the
+// go command, which then runs each tool via us, so we can see its inputs.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LogEnv is the env var naming the file that inputs are appended to. Its
+// being set is what tells us we're being run as the -toolexec wrapper.
+const LogEnv = "ACTIONS_GO_BUILD_AUDIT_LOG"
+
+// IsToolexec returns true if args, as passed to main, look like we're being
+// run as the -toolexec wrapper, i.e. to run a Go tool.
+func IsToolexec(args []string) bool {
+	return os.Getenv(LogEnv) != "" && len(args) > 1 &&
+		filepath.IsAbs(args[1]) && strings.Contains(filepath.ToSlash(args[1]), "/pkg/tool/")
+}
+
+// Toolexec records the inputs of the tool, then runs it with args and
+// returns its exit code.
+func Toolexec(tool string, args []string) int {
+	if err := record(os.Getenv(LogEnv), tool, args); err != nil {
+		fmt.Fprintf(os.Stderr, "actions-go-build: recording inputs of %s: %s\n", filepath.Base(tool), err)
+		return 1
+	}
+	cmd := exec.Command(tool, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "actions-go-build: running %s: %s\n", tool, err)
+		return 1
+	}
+	return 0
+}
+
+// record appends the absolute paths of the source files read by tool to
+// the log file, one per line.
+func record(logPath, tool string, args []string) error {
+	args, err := expandArgs(args)
+	if err != nil {
+		return err
+	}
+	inputs, err := Inputs(strings.TrimSuffix(filepath.Base(tool), ".exe"), args)
+	if err != nil || len(inputs) == 0 {
+		return err
+	}
+	var b strings.Builder
+	for _, in := range inputs {
+		abs, err := filepath.Abs(in)
+		if err != nil {
+			return err
+		}
+		b.WriteString(abs + "\n")
+	}
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	// Tools run in parallel, so write all the lines at once.
+	if _, err := f.WriteString(b.String()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Inputs returns the source files named in the args of the Go tool called
+// name. Only the compiler, assembler, and cgo read source files.
+func Inputs(name string, args []string) ([]string, error) {
+	var inputs []string
+	switch name {
+	case "compile":
+		for i := 0; i < len(args); i++ {
+			a := args[i]
+			switch {
+			case a == "-embedcfg" && i+1 < len(args):
+				i++
+				files, err := embedFiles(args[i])
+				if err != nil {
+					return nil, err
+				}
+				inputs = append(inputs, files...)
+			case strings.HasPrefix(a, "-embedcfg="):
+				files, err := embedFiles(strings.TrimPrefix(a, "-embedcfg="))
+				if err != nil {
+					return nil, err
+				}
+				inputs = append(inputs, files...)
+			case !strings.HasPrefix(a, "-") && strings.HasSuffix(a, ".go"):
+				inputs = append(inputs, a)
+			}
+		}
+	case "asm":
+		for _, a := range args {
+			if !strings.HasPrefix(a, "-") && strings.HasSuffix(a, ".s") {
+				inputs = append(inputs, a)
+			}
+		}
+	case "cgo":
+		// The files come after "--" and the compiler flags.
+		for i, a := range args {
+			if a != "--" {
+				continue
+			}
+			for _, f := range args[i+1:] {
+				if strings.HasSuffix(f, ".go") {
+					inputs = append(inputs, f)
+				}
+			}
+			break
+		}
+	}
+	return inputs, nil
+}
+
+// embedFiles returns the files listed in the compiler's -embedcfg file.
+func embedFiles(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Files map[string]string
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing embed config %s: %w", path, err)
+	}
+	var files []string
+	for _, f := range cfg.Files {
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// expandArgs replaces "@file" args with the args in file, one per line,
+// which is how the go command passes long argument lists.
+func expandArgs(args []string) ([]string, error) {
+	var out []string
+	for _, a := range args {
+		if !strings.HasPrefix(a, "@") {
+			out = append(out, a)
+			continue
+		}
+		f, err := os.Open(a[1:])
+		if err != nil {
+			return nil, err
+		}
+		s := bufio.NewScanner(f)
+		s.Buffer(nil, 1<<20)
+		for s.Scan() {
+			line := s.Text()
+			if strings.HasPrefix(line, `"`) {
+				if line, err = strconv.Unquote(line); err != nil {
+					_ = f.Close()
+					return nil, err
+				}
+			}
+			out = append(out, line)
+		}
+		err = s.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
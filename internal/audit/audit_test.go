@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInputs(t *testing.T) {
+	dir := t.TempDir()
+	embedcfg := filepath.Join(dir, "embedcfg")
+	if err := os.WriteFile(embedcfg, []byte(`{"Patterns":{"static":["static/a.txt"]},"Files":{"static/a.txt":"/src/static/a.txt"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		tool string
+		args []string
+		want []string
+	}{
+		{
+			"compile",
+			[]string{"-o", "/tmp/_pkg_.a", "-p", "main", "-embedcfg", embedcfg, "-pack", "./main.go", "/src/util.go"},
+			[]string{"/src/static/a.txt", "./main.go", "/src/util.go"},
+		},
+		{
+			"asm",
+			[]string{"-p", "main", "-o", "/tmp/a.o", "./a_amd64.s"},
+			[]string{"./a_amd64.s"},
+		},
+		{
+			"cgo",
+			[]string{"-objdir", "/tmp/x", "-importpath", "main", "--", "-I", "/tmp/x", "./c.go"},
+			[]string{"./c.go"},
+		},
+		{
+			"link",
+			[]string{"-o", "/tmp/a.out", "/tmp/_pkg_.a"},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.tool, func(t *testing.T) {
+			got, err := Inputs(c.tool, c.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "args")
+	if err := os.WriteFile(path, []byte("-p\nmain\n\"./a b.go\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := expandArgs([]string{"-pack", "@" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-pack", "-p", "main", "./a b.go"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package bundle finds build results, and the product zips they describe,
+// in a directory, zip, or tarball, such as a GitHub release or a workflow
+// artifact. Nothing is extracted: result files are read into memory and
+// product zips are only hashed.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/pkg/digest"
+)
+
+// Result file name suffixes.
+const (
+	BuildResultSuffix        = ".buildresult.json"
+	VerificationResultSuffix = ".verificationresult.json"
+)
+
+// MaxResultSize is the largest result file we will read.
+const MaxResultSize = 64 << 20
+
+// archiveSuffixes are the file name suffixes of the archives we can read.
+var archiveSuffixes = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive returns true if name has the suffix of an archive we can read.
+func IsArchive(name string) bool {
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsResultFile returns true if name is the name of a build or verification
+// result file.
+func IsResultFile(name string) bool {
+	return strings.HasSuffix(name, BuildResultSuffix) || strings.HasSuffix(name, VerificationResultSuffix)
+}
+
+// HasResults returns true if dir contains any result files at its top level.
+func HasResults(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() && IsResultFile(e.Name()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Bundle is the contents of a directory or archive that we care about.
+// All paths are slash-separated and relative to the bundle root.
+type Bundle struct {
+	// Location is the directory or archive the bundle was read from.
+	Location string
+	// Results are the result files, sorted by path.
+	Results []ResultFile
+	// Zips are all the zip files, sorted by path.
+	Zips []Zip
+}
+
+// ResultFile is a build or verification result file.
+type ResultFile struct {
+	Path string
+	Data []byte
+}
+
+// Zip is a zip file in the bundle.
+type Zip struct {
+	Path      string
+	Size      int64
+	SHA256Sum string
+}
+
+// ErrZipNotFound is returned by FindZip when there's no zip with that name.
+var ErrZipNotFound = errors.New("zip not found")
+
+// Read reads the directory or archive at location, which must be a
+// directory or have one of the archive suffixes.
+func Read(location string) (*Bundle, error) {
+	b := &Bundle{Location: location}
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.IsDir():
+		err = b.readFS(os.DirFS(location))
+	case strings.HasSuffix(location, ".zip"):
+		err = b.readZip(location)
+	case strings.HasSuffix(location, ".tar"):
+		err = b.readTarFile(location, false)
+	case strings.HasSuffix(location, ".tar.gz"), strings.HasSuffix(location, ".tgz"):
+		err = b.readTarFile(location, true)
+	default:
+		err = fmt.Errorf("not a directory or a %s file", strings.Join(archiveSuffixes, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", location, err)
+	}
+	sort.Slice(b.Results, func(i, j int) bool { return b.Results[i].Path < b.Results[j].Path })
+	sort.Slice(b.Zips, func(i, j int) bool { return b.Zips[i].Path < b.Zips[j].Path })
+	return b, nil
+}
+
+func (b *Bundle) readFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return b.add(p, f)
+	})
+}
+
+func (b *Bundle) readZip(location string) error {
+	zr, err := zip.OpenReader(location)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return b.readFS(zr)
+}
+
+func (b *Bundle) readTarFile(location string, gzipped bool) error {
+	f, err := os.Open(location)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := b.add(path.Clean(strings.TrimPrefix(h.Name, "/")), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// add records the file at p if it's a result or a zip.
+func (b *Bundle) add(p string, r io.Reader) error {
+	switch {
+	case IsResultFile(p):
+		data, err := io.ReadAll(io.LimitReader(r, MaxResultSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > MaxResultSize {
+			return fmt.Errorf("%s is larger than %d bytes", p, MaxResultSize)
+		}
+		b.Results = append(b.Results, ResultFile{Path: p, Data: data})
+	case strings.HasSuffix(p, ".zip"):
+		cr := &countingReader{r: r}
+		sum, err := digest.SHA256Hex(cr)
+		if err != nil {
+			return err
+		}
+		b.Zips = append(b.Zips, Zip{Path: p, Size: cr.n, SHA256Sum: sum})
+	}
+	return nil
+}
+
+// FindZip returns the zip called name that belongs to the result at
+// resultPath. That's the one in the same directory if there is one,
+// otherwise the only one with that name anywhere in the bundle.
+func (b *Bundle) FindZip(resultPath, name string) (Zip, error) {
+	want := path.Join(path.Dir(resultPath), name)
+	var matches []Zip
+	for _, z := range b.Zips {
+		if z.Path == want {
+			return z, nil
+		}
+		if path.Base(z.Path) == name {
+			matches = append(matches, z)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Zip{}, fmt.Errorf("%w: %s", ErrZipNotFound, name)
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, z := range matches {
+		paths[i] = z.Path
+	}
+	return Zip{}, fmt.Errorf("found %d zips called %s: %s", len(matches), name, strings.Join(paths, ", "))
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type entry struct{ name, contents string }
+
+var testEntries = []entry{
+	{"README.md", "ignored"},
+	{"dist/linux/example_1.0.0_linux_amd64.zip", "linux zip"},
+	{"dist/linux/example_1.0.0_linux_amd64.zip.buildresult.json", `{"linux":true}`},
+	{"dist/darwin/example_1.0.0_darwin_arm64.zip", "darwin zip"},
+	{"dist/darwin/example_1.0.0_darwin_arm64.zip.verificationresult.json", `{"darwin":true}`},
+}
+
+func sha(s string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(s))) }
+
+func wantBundle(location string) *Bundle {
+	return &Bundle{
+		Location: location,
+		Results: []ResultFile{
+			{Path: "dist/darwin/example_1.0.0_darwin_arm64.zip.verificationresult.json", Data: []byte(`{"darwin":true}`)},
+			{Path: "dist/linux/example_1.0.0_linux_amd64.zip.buildresult.json", Data: []byte(`{"linux":true}`)},
+		},
+		Zips: []Zip{
+			{Path: "dist/darwin/example_1.0.0_darwin_arm64.zip", Size: 10, SHA256Sum: sha("darwin zip")},
+			{Path: "dist/linux/example_1.0.0_linux_amd64.zip", Size: 9, SHA256Sum: sha("linux zip")},
+		},
+	}
+}
+
+func writeDir(t *testing.T, entries []entry) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, e := range entries {
+		p := filepath.Join(dir, filepath.FromSlash(e.name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(e.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func writeZip(t *testing.T, entries []entry) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "artifact.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, e.contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func writeTar(t *testing.T, name string, entries []entry) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if !strings.HasSuffix(name, ".tar") {
+		gz := gzip.NewWriter(f)
+		defer func() {
+			if err := gz.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: "dist/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		h := &tar.Header{Name: "./" + e.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(e.contents))}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, e.contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRead(t *testing.T) {
+	cases := map[string]string{
+		"dir":    writeDir(t, testEntries),
+		"zip":    writeZip(t, testEntries),
+		"tar":    writeTar(t, "release.tar", testEntries),
+		"tar.gz": writeTar(t, "release.tar.gz", testEntries),
+		"tgz":    writeTar(t, "release.tgz", testEntries),
+	}
+	for name, location := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Read(location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := wantBundle(location); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRead_err(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "result.json")
+	if err := os.WriteFile(p, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(p); err == nil {
+		t.Errorf("got nil error reading %s", p)
+	}
+	big := []entry{{"x.buildresult.json", strings.Repeat(" ", MaxResultSize+1)}}
+	if _, err := Read(writeDir(t, big)); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("got error %v; want too large", err)
+	}
+}
+
+func TestBundle_FindZip(t *testing.T) {
+	b := &Bundle{Zips: []Zip{
+		{Path: "a/example.zip", SHA256Sum: "a"},
+		{Path: "b/example.zip", SHA256Sum: "b"},
+		{Path: "b/other.zip", SHA256Sum: "other"},
+		{Path: "c/only.zip", SHA256Sum: "only"},
+	}}
+	for _, c := range []struct {
+		result, name, want, wantErr string
+	}{
+		{"a/example.zip.buildresult.json", "example.zip", "a", ""},
+		{"b/example.zip.buildresult.json", "example.zip", "b", ""},
+		{"c/example.zip.buildresult.json", "example.zip", "", "found 2 zips called example.zip"},
+		{"results/only.zip.buildresult.json", "only.zip", "only", ""},
+		{"x.buildresult.json", "missing.zip", "", "zip not found: missing.zip"},
+	} {
+		got, err := b.FindZip(c.result, c.name)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("FindZip(%q, %q) error = %v; want %q", c.result, c.name, err, c.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FindZip(%q, %q): %s", c.result, c.name, err)
+		} else if got.SHA256Sum != c.want {
+			t.Errorf("FindZip(%q, %q) = %+v; want %q", c.result, c.name, got, c.want)
+		}
+	}
+	if _, err := b.FindZip("x", "missing.zip"); !errors.Is(err, ErrZipNotFound) {
+		t.Errorf("got %v; want ErrZipNotFound", err)
+	}
+}
+
+func TestHasResults(t *testing.T) {
+	dir := writeDir(t, testEntries)
+	for d, want := range map[string]bool{
+		dir:                              false,
+		filepath.Join(dir, "dist/linux"): true,
+	} {
+		got, err := HasResults(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("HasResults(%s) = %t; want %t", d, got, want)
+		}
+	}
+}
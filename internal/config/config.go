@@ -4,14 +4,12 @@
 package config
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
 	"github.com/hashicorp/actions-go-build/pkg/build"
 	"github.com/hashicorp/actions-go-build/pkg/crt"
 	"github.com/hashicorp/actions-go-build/pkg/digest"
-	"github.com/sethvargo/go-envconfig"
 )
 
 // ConfigIDFunc can be overridden in tests to provide a stable ID.
@@ -37,6 +35,13 @@ type Config struct {
 	//   - "nope"   - don't run the verification build at all.
 	Reproducible string `env:"REPRODUCIBLE"`
 
+	// VersionStrategy determines how the core version is read from the
+	// repository when it's not set explicitly. See crt.VersionStrategy.
+	VersionStrategy string `env:"VERSION_STRATEGY"`
+
+	// VersionPolicy determines how strictly the product version is validated.
+	VersionPolicy crt.VersionPolicy
+
 	// Tool is the version of actions-go-build that created this config.
 	Tool crt.Tool
 
@@ -50,6 +55,13 @@ type Config struct {
 	Verification Paths `env:",prefix=VERIFICATION_"`
 
 	VerificationResult string `env:"VERIFICATION_RESULT"`
+
+	// Sources records where each of the above values was read from.
+	Sources Sources `json:",omitempty"`
+
+	// Origins records where each value not read from a source came from.
+	// It's only populated if non-nil before init is called.
+	Origins crt.Origins `json:"-"`
 }
 
 type Paths struct {
@@ -65,20 +77,35 @@ type Paths struct {
 }
 
 // FromEnvironment creates a new Config from environment variables
-// and repository context in the current working directory.
-func FromEnvironment(creator crt.Tool, dir string) (Config, error) {
-	var c Config
-	ctx := context.Background()
-	if err := envconfig.Process(ctx, &c); err != nil {
+// and repository context in dir. Values are also read from a config file
+// in dir (see FilePaths) and any flags passed using opts. Flags take
+// precedence over the environment, which takes precedence over the file.
+func FromEnvironment(creator crt.Tool, dir string, opts ...Option) (Config, error) {
+	c := Config{Origins: crt.Origins{}}
+	l, err := newLoader(dir, opts...)
+	if err != nil {
+		return c, err
+	}
+	if err := l.process(&c); err != nil {
+		return c, err
+	}
+
+	vs, err := crt.ParseVersionStrategy(c.VersionStrategy)
+	if err != nil {
 		return c, err
 	}
 
-	rc, err := crt.GetRepoContext(dir, build.Dirs.List())
+	rc, err := crt.GetRepoContext(dir, build.Dirs.List(), vs)
 	if err != nil {
 		return c, err
 	}
 
-	return c.init(rc, creator)
+	if c, err = c.init(rc, creator); err != nil {
+		return c, err
+	}
+	// Set sources after init so they don't affect the config ID.
+	c.Sources = l.sources
+	return c, nil
 }
 
 // buildConfig returns a BuildConfig based on this Config, rooted at root.
@@ -95,7 +122,9 @@ func (c Config) buildConfig(root string) (build.Config, error) {
 	if c.Reproducible == "assert" {
 		reproducible = true
 	}
-	return build.NewConfig(c.Product, c.Parameters, paths, c.Tool, reproducible)
+	bc, err := build.NewConfig(c.Product, c.Parameters, paths, c.Tool, reproducible)
+	bc.Sources = c.Sources
+	return bc, err
 }
 
 // PrimaryBuildConfig returns the config for the primary build.
@@ -110,24 +139,41 @@ func (c Config) VerificationBuildConfig() (build.Config, error) {
 
 func (c Config) init(rc crt.RepoContext, creator crt.Tool) (Config, error) {
 	var err error
-	if c.Product, err = c.Product.Init(rc); err != nil {
+	if err := c.VersionPolicy.Validate(); err != nil {
+		return c, err
+	}
+	o := c.Origins
+	if c.Product, err = c.Product.InitWithOrigins(rc, o); err != nil {
+		return c, err
+	}
+	if err := c.VersionPolicy.Check(c.Product.Version, rc); err != nil {
 		return c, err
 	}
-	if c.Parameters, err = c.Parameters.Init(c.Product); err != nil {
+	if c.Parameters, err = c.Parameters.InitWithOrigins(c.Product, o); err != nil {
 		return c, err
 	}
+	if c.Reproducible == "" {
+		o.Set("REPRODUCIBLE", "default")
+	}
 	if c.Reproducible, err = c.resolveReproducible(); err != nil {
 		return c, err
 	}
+	if c.Reproducible == "nope" {
+		o.Warn("REPRODUCIBLE", "no verification build will be run")
+	}
 
 	if strings.ToLower(c.Parameters.OS) == "windows" {
-		c.Product.ExecutableName = ensureExtension(c.Product.ExecutableName, ".exe")
+		if name := ensureExtension(c.Product.ExecutableName, ".exe"); name != c.Product.ExecutableName {
+			c.Product.ExecutableName = name
+			o.Warn("BIN_NAME", ".exe extension added for windows")
+		}
 	}
 
 	primaryPaths := build.NewPrimaryDirs(c.Product, c.Parameters, creator)
 
 	if c.Primary.BuildResult == "" {
 		c.Primary.BuildResult = primaryPaths.BuildResultCacheDir()
+		o.Set("PRIMARY_BUILD_RESULT", "cache dir for this product, source hash, and parameters")
 	}
 
 	verificationPaths := build.NewVerificationDirs(c.Product, c.Parameters, creator)
@@ -135,19 +181,23 @@ func (c Config) init(rc crt.RepoContext, creator crt.Tool) (Config, error) {
 	// Default the primary build root to the current directory.
 	if c.Primary.BuildRoot == "" {
 		c.Primary.BuildRoot = rc.Dir
+		o.Set("PRIMARY_BUILD_ROOT", "product directory")
 	}
 	if c.Verification.BuildRoot == "" {
 		c.Verification.BuildRoot = verificationPaths.RemoteBuildRoot()
+		o.Set("VERIFICATION_BUILD_ROOT", "temp dir for this product, source hash, and parameters")
 	}
 
 	if c.Verification.BuildResult == "" {
 		c.Verification.BuildResult = verificationPaths.BuildResultCacheDir()
+		o.Set("VERIFICATION_BUILD_RESULT", "cache dir for this product, source hash, and parameters")
 	}
 
 	c.Tool = creator
 
 	if c.VerificationResult == "" {
 		c.VerificationResult = verificationPaths.VerificationResultCachePath(ConfigIDFunc(c), c.Parameters.ZipName)
+		o.Set("VERIFICATION_RESULT", "cache dir for this config")
 	}
 
 	return c, nil
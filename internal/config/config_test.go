@@ -5,6 +5,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,11 +22,13 @@ func TestConfig_init_ok(t *testing.T) {
 	build.TempDirFunc = func() string { return "/test/temp/dir" }
 	build.ConfigIDFunc = func(build.Config) string { return "<build-config-id>" }
 	build.CacheKeyFunc = func(...any) string { return "<compound-cache-key>" }
+	oldWorktreeStateFunc := crt.WorktreeStateFunc
 	crt.WorktreeStateFunc = func(string, []string) (*git.WorktreeState, error) {
 		return &git.WorktreeState{
 			SourceHash: "<sourcehash>",
 		}, nil
 	}
+	t.Cleanup(func() { crt.WorktreeStateFunc = oldWorktreeStateFunc })
 
 	ConfigIDFunc = func(Config) string { return "<config-id>" }
 
@@ -120,6 +123,52 @@ func TestConfig_init_ok(t *testing.T) {
 	}
 }
 
+func TestConfig_init_err(t *testing.T) {
+
+	cases := []struct {
+		description string
+		inputs      Config
+		want        string
+	}{
+		{
+			"invalid version policy",
+			testUninitializedConfig(func(i *Config) {
+				i.VersionPolicy.Mode = "strict"
+			}),
+			`"strict" is not a valid version policy`,
+		},
+		{
+			"release policy, version disagrees with VERSION file",
+			testUninitializedConfig(func(i *Config) {
+				i.VersionPolicy.Mode = "release"
+				i.Product.Version.Full = "1.2.4+ent"
+			}),
+			`core version "1.2.4" does not match "1.2.3"`,
+		},
+		{
+			"release policy, disallowed metadata",
+			testUninitializedConfig(func(i *Config) {
+				i.VersionPolicy.Mode = "release"
+				i.Product.Version.Meta = "blah"
+			}),
+			`metadata "blah" does not match`,
+		},
+	}
+
+	for _, c := range cases {
+		description, inputs, want := c.description, c.inputs, c.want
+		t.Run(description, func(t *testing.T) {
+			_, err := inputs.init(testRepoContext(), crt.Tool{})
+			if err == nil {
+				t.Fatalf("got nil error; want error containing %q", want)
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("got error %q; want it to contain %q", err, want)
+			}
+		})
+	}
+}
+
 // testRepoContext generates a RepoContext for testing by taking the standard
 // RepoContext and applying the provided modifier functions to it in the order
 // provided.
@@ -31,6 +31,13 @@ func (c Config) EnvVars() ([]EnvVar, error) {
 	addEnv := func(key, value string) {
 		kvs = append(kvs, EnvVar{key, value})
 	}
+	// addEnvIfSet is for optional build parameters, so that exporting an
+	// empty value doesn't override one set elsewhere in the job.
+	addEnvIfSet := func(key, value string) {
+		if value != "" {
+			addEnv(key, value)
+		}
+	}
 
 	// TODO don't serialise primary and verification build configs to env here.
 	// We can derive them from the rest of the config anyway so there's probably
@@ -56,7 +63,22 @@ func (c Config) EnvVars() ([]EnvVar, error) {
 	addEnv("GO_VERSION", c.Parameters.GoVersion)
 	addEnv("OS", c.Parameters.OS)
 	addEnv("ARCH", c.Parameters.Arch)
+	addEnvIfSet("BUILD_TAGS", c.Parameters.Tags)
+	addEnvIfSet("BUILD_CGO_ENABLED", c.Parameters.CGOEnabled)
+	addEnvIfSet("BUILD_GOARM", c.Parameters.GOARM)
+	addEnvIfSet("BUILD_GOAMD64", c.Parameters.GOAMD64)
+	addEnvIfSet("MAIN_PACKAGE", c.Parameters.MainPackage)
+	addEnvIfSet("EXTRA_LDFLAGS", c.Parameters.LDFlags)
+	addEnvIfSet("BUILD_CC", c.Parameters.CC)
+	addEnvIfSet("BUILD_CXX", c.Parameters.CXX)
+	addEnvIfSet("BUILD_ZIG", c.Parameters.Zig)
+	addEnvIfSet("BUILD_SYSROOT", c.Parameters.Sysroot)
+	addEnvIfSet("BUILD_CGO_CFLAGS", c.Parameters.CGOCFlags)
 	addEnv("REPRODUCIBLE", c.Reproducible)
+	addEnv("VERSION_STRATEGY", c.VersionStrategy)
+	addEnv("VERSION_POLICY", c.VersionPolicy.Mode)
+	addEnv("VERSION_PRERELEASES", c.VersionPolicy.Prereleases)
+	addEnv("VERSION_METADATA_PATTERN", c.VersionPolicy.MetadataPattern)
 	addEnv("INSTRUCTIONS", c.Parameters.Instructions)
 	addEnv("BIN_NAME", c.Product.ExecutableName)
 	addEnv("ZIP_NAME", c.Parameters.ZipName)
@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+// Explanation describes where a single resolved config value came from.
+type Explanation struct {
+	Name     string
+	Value    string
+	Source   string
+	Warnings []string `json:",omitempty"`
+}
+
+// Explain returns an Explanation for each of the variables in EnvVars.
+// Values read from a flag, the environment, or a config file are explained
+// by Sources, and other values by Origins. Any remaining values are either
+// defaults (if empty) or derived from other values.
+func (c Config) Explain() ([]Explanation, error) {
+	vars, err := c.EnvVars()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Explanation, len(vars))
+	for i, v := range vars {
+		e := Explanation{Name: v.Name, Value: v.Value}
+		o := c.Origins[v.Name]
+		if o != nil {
+			e.Source = o.Source
+			e.Warnings = o.Warnings
+		}
+		if s, ok := c.Sources[v.Name]; ok {
+			e.Source = s
+		}
+		if e.Source == "" {
+			switch v.Value {
+			case "", "false":
+				e.Source = "default"
+			default:
+				e.Source = "derived"
+			}
+		}
+		out[i] = e
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+	"github.com/hashicorp/go-version"
+)
+
+func TestConfig_Explain(t *testing.T) {
+
+	ConfigIDFunc = func(Config) string { return "<config-id>" }
+
+	inputs := testUninitializedConfig(func(c *Config) {
+		c.Parameters.OS = "windows"
+		c.Reproducible = "nope"
+		c.Origins = crt.Origins{}
+	})
+	rc := testRepoContext(func(rc *crt.RepoContext) {
+		rc.Dir = "/some/dir/work/cmd/lockbox"
+		rc.CoreVersion = *version.Must(version.NewVersion("0.0.0-version-file-missing"))
+		rc.CoreVersionSource = "default (no VERSION file)"
+		rc.DirtyFiles = []string{"a", "b"}
+	})
+	c, err := inputs.init(rc, crt.Tool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Sources = Sources{"GO_VERSION": "env", "OS": "file:.release/build.hcl", "ARCH": "env", "REPRODUCIBLE": "flag"}
+
+	explanations, err := c.Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]Explanation{}
+	for _, e := range explanations {
+		got[e.Name] = e
+	}
+
+	want := []Explanation{
+		{Name: "PRODUCT_REPOSITORY", Value: "dadgarcorp/lockbox", Source: "repository name"},
+		{Name: "PRODUCT_NAME", Value: "lockbox", Source: "product directory name"},
+		{
+			Name: "PRODUCT_VERSION", Value: "0.0.0-version-file-missing", Source: "default (no VERSION file)",
+			Warnings: []string{"no VERSION file found; using default 0.0.0-version-file-missing"},
+		},
+		{
+			Name: "PRODUCT_REVISION", Value: "<commit-sha>", Source: "HEAD commit",
+			Warnings: []string{"worktree has 2 dirty files, so the build isn't of this revision alone"},
+		},
+		{Name: "GO_VERSION", Value: "1.18", Source: "env"},
+		{Name: "OS", Value: "windows", Source: "file:.release/build.hcl"},
+		{Name: "ARCH", Value: "amd64", Source: "env"},
+		{Name: "REPRODUCIBLE", Value: "nope", Source: "flag", Warnings: []string{"no verification build will be run"}},
+		{
+			Name: "BIN_NAME", Value: "lockbox.exe", Source: "product name without -enterprise suffix",
+			Warnings: []string{".exe extension added for windows"},
+		},
+		{Name: "ZIP_NAME", Value: "lockbox_0.0.0-version-file-missing_windows_amd64.zip", Source: "product name, version, OS, and architecture"},
+		{Name: "PRIMARY_BUILD_ROOT", Value: "/some/dir/work/cmd/lockbox", Source: "product directory"},
+		{Name: "VERSION_STRATEGY", Value: "", Source: "default"},
+		{Name: "DEBUG", Value: "false", Source: "default"},
+		{Name: "BIN_PATH_PRIMARY", Value: "/some/dir/work/cmd/lockbox/dist/lockbox.exe", Source: "derived"},
+	}
+
+	for _, w := range want {
+		if diff := cmp.Diff(got[w.Name], w); diff != "" {
+			t.Errorf("%s: %s", w.Name, diff)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/actions-go-build/internal/manifest"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+// FromManifest reads the products manifest in the repository root rootDir,
+// and returns one Config per product per platform. Settings not declared in
+// the manifest are read from the environment as in FromEnvironment, apart
+// from those that can only apply to a single product: the product version,
+// zip name, and build root and result paths. A config file in rootDir
+// and opts are used as in FromEnvironment.
+func FromManifest(creator crt.Tool, rootDir string, opts ...Option) ([]Config, error) {
+	m, err := manifest.Read(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	l, err := newLoader(rootDir, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var base Config
+	if err := l.process(&base); err != nil {
+		return nil, err
+	}
+	base.Sources = l.sources
+	var out []Config
+	for _, p := range m.Products {
+		configs, err := base.forProduct(creator, rootDir, m, p)
+		if err != nil {
+			return nil, fmt.Errorf("product %q: %w", p.Name, err)
+		}
+		out = append(out, configs...)
+	}
+	return out, nil
+}
+
+func (c Config) forProduct(creator crt.Tool, rootDir string, m manifest.Manifest, p manifest.Product) ([]Config, error) {
+	c.Sources = c.Sources.clone()
+	set := func(key string, field *string, value string) {
+		*field = value
+		c.Sources[key] = SourceManifest
+	}
+	set("PRODUCT_NAME", &c.Product.Name, p.Name)
+	if p.ExecutableName != "" {
+		set("BIN_NAME", &c.Product.ExecutableName, p.ExecutableName)
+	} else {
+		c.Product.ExecutableName = ""
+		delete(c.Sources, "BIN_NAME")
+	}
+	if p.VersionStrategy != "" {
+		set("VERSION_STRATEGY", &c.VersionStrategy, p.VersionStrategy)
+	}
+	if p.Instructions != "" {
+		set("INSTRUCTIONS", &c.Parameters.Instructions, p.Instructions)
+	}
+	c.Product.Version.Full = ""
+	c.Parameters.ZipName = ""
+	c.Primary = Paths{TargetDir: c.Primary.TargetDir}
+	c.Verification = Paths{TargetDir: c.Verification.TargetDir}
+	c.VerificationResult = ""
+	for _, k := range []string{"PRODUCT_VERSION", "ZIP_NAME", "PRIMARY_BUILD_ROOT", "VERIFICATION_BUILD_ROOT", "VERIFICATION_RESULT"} {
+		delete(c.Sources, k)
+	}
+
+	vs, err := crt.ParseVersionStrategy(c.VersionStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ignore the dist, out, and meta dirs inside the product dir.
+	ignore := build.Dirs.List()
+	if dir := filepath.ToSlash(filepath.Clean(p.Dir)); dir != "." {
+		for i, d := range ignore {
+			ignore[i] = dir + "/" + d
+		}
+	}
+	rc, err := crt.GetRepoContext(filepath.Join(rootDir, p.Dir), ignore, vs, m.Scope(p)...)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms, err := p.PlatformList()
+	if err != nil {
+		return nil, err
+	}
+	if len(platforms) == 0 {
+		platforms = []manifest.Platform{{OS: c.Parameters.OS, Arch: c.Parameters.Arch}}
+	}
+	out := make([]Config, len(platforms))
+	for i, pl := range platforms {
+		pc := c
+		pc.Sources = nil
+		pc.Origins = crt.Origins{}
+		if len(p.Platforms) != 0 {
+			pc.Parameters.OS, pc.Parameters.Arch = pl.OS, pl.Arch
+		}
+		if out[i], err = pc.init(rc, creator); err != nil {
+			return nil, fmt.Errorf("platform %s: %w", pl, err)
+		}
+		// Set sources after init so they don't affect the config ID.
+		out[i].Sources = c.Sources.clone()
+		if len(p.Platforms) != 0 {
+			out[i].Sources["OS"], out[i].Sources["ARCH"] = SourceManifest, SourceManifest
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+func TestFromManifest(t *testing.T) {
+
+	ConfigIDFunc = func(Config) string { return "<config-id>" }
+
+	dir := writeProductsRepo(t, map[string]string{
+		".release/products.json": `{"products": [
+			{"name": "alpha", "dir": "cmd/alpha", "platforms": ["linux/amd64", "darwin/arm64"]},
+			{"name": "beta-enterprise", "dir": "cmd/beta", "executable_name": "b", "version_strategy": "env:BETA_VERSION"}
+		]}`,
+		"cmd/alpha/VERSION": "1.0.0",
+		"cmd/beta/main.go":  "package main",
+	})
+
+	// Use the origin remote for the repository name, wherever the test runs.
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("OS", "windows")
+	t.Setenv("ARCH", "386")
+	t.Setenv("PRODUCT_NAME", "ignored")
+	t.Setenv("PRODUCT_VERSION", "9.9.9")
+	t.Setenv("BETA_VERSION", "2.0.0")
+
+	configs, err := FromManifest(crt.Tool{Name: "test", Version: "0.0.0", Revision: "test"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		Repository, Name, ExecutableName, Version, ZipName, BuildRoot string
+	}
+	got := make([]summary, len(configs))
+	for i, c := range configs {
+		got[i] = summary{c.Product.Repository, c.Product.Name, c.Product.ExecutableName, c.Product.Version.Full, c.Parameters.ZipName, c.Primary.BuildRoot}
+	}
+	want := []summary{
+		{"dadgarcorp/products", "alpha", "alpha", "1.0.0", "alpha_1.0.0_linux_amd64.zip", filepath.Join(dir, "cmd/alpha")},
+		{"dadgarcorp/products", "alpha", "alpha", "1.0.0", "alpha_1.0.0_darwin_arm64.zip", filepath.Join(dir, "cmd/alpha")},
+		{"dadgarcorp/products", "beta-enterprise", "b.exe", "2.0.0", "beta-enterprise_2.0.0_windows_386.zip", filepath.Join(dir, "cmd/beta")},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestFromManifest_dirWithDot checks a product dir is matched literally when
+// ignoring its dist, out, and meta dirs.
+func TestFromManifest_dirWithDot(t *testing.T) {
+
+	ConfigIDFunc = func(Config) string { return "<config-id>" }
+
+	dir := writeProductsRepo(t, map[string]string{
+		".release/products.json": `{"products": [
+			{"name": "gamma", "dir": "svc.api", "shared_paths": ["svc-api"], "platforms": ["linux/amd64"]}
+		]}`,
+		"svc.api/VERSION":     "1.0.0",
+		"svc.api/dist/gamma":  "built",
+		"svc-api/dist/gen.go": "package dist",
+	})
+	t.Setenv("GITHUB_REPOSITORY", "")
+
+	configs, err := FromManifest(crt.Tool{Name: "test", Version: "0.0.0", Revision: "test"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"svc-api/dist/gen.go", "svc.api/VERSION"}
+	if diff := cmp.Diff(want, configs[0].Product.DirtyFiles); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// writeProductsRepo writes files, uncommitted, to a new git repository with
+// an empty initial commit and an origin remote, and returns its path.
+func writeProductsRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := tmp.Dir(t)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+		{"remote", "add", "origin", "https://github.com/dadgarcorp/products.git"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s: %s", args, err, out)
+		}
+	}
+	return dir
+}
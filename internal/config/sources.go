@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/sethvargo/go-envconfig"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"gopkg.in/yaml.v3"
+)
+
+// FilePaths are the places we look for a config file, relative to the
+// product directory, in order of preference.
+var FilePaths = []string{".release/build.hcl", ".release/build.yaml", ".release/build.yml"}
+
+// Where config values can come from, in increasing order of precedence.
+const (
+	SourceFile     = "file"
+	SourceEnv      = "env"
+	SourceWorkflow = "workflow"
+	SourceFlag     = "flag"
+	// SourceManifest marks values set by the products manifest.
+	SourceManifest = "manifest"
+)
+
+// Sources records where each config value was read from. It's keyed by
+// environment variable name, and each value is one of SourceEnv, SourceFlag,
+// or SourceFile or SourceWorkflow followed by a colon and the file path,
+// or SourceManifest. Values derived from
+// defaults or the repository context have no entry.
+type Sources map[string]string
+
+func (s Sources) clone() Sources {
+	out := make(Sources, len(s))
+	for k, v := range s {
+		out[k] = v
+	}
+	return out
+}
+
+// Option configures where a Config is read from.
+type Option func(*loader)
+
+// WithFile reads config from the file at path, rather than searching FilePaths.
+func WithFile(path string) Option { return func(l *loader) { l.file = path } }
+
+// WithFlags sets config values that take precedence over both the environment
+// and any config file. Keys are environment variable names, in upper or lower case.
+func WithFlags(values map[string]string) Option {
+	return func(l *loader) {
+		l.flags = make(map[string]string, len(values))
+		for k, v := range values {
+			l.flags[strings.ToUpper(k)] = v
+		}
+	}
+}
+
+// WithWorkflow sets config values read from the workflow file named name.
+// They take precedence over the environment and any config file, but not
+// flags. Empty values are ignored, as they are in the environment.
+func WithWorkflow(name string, values map[string]string) Option {
+	return func(l *loader) {
+		l.workflowName = name
+		l.workflow = values
+	}
+}
+
+// loader reads config values from flags, then a workflow, then the
+// environment, then a file, recording which one each value came from.
+type loader struct {
+	file  string
+	flags map[string]string
+	// workflow are values read from the workflow file workflowName.
+	workflow     map[string]string
+	workflowName string
+	// fileName is file relative to the product dir, if it's inside it.
+	fileName string
+
+	// fileValues are the values read from file.
+	fileValues map[string]string
+	// used records the file keys that were looked up.
+	used    map[string]bool
+	sources Sources
+}
+
+func newLoader(dir string, opts ...Option) (*loader, error) {
+	l := &loader{used: map[string]bool{}, sources: Sources{}}
+	for _, o := range opts {
+		o(l)
+	}
+	if l.file == "" {
+		var err error
+		if l.file, err = findFile(dir); err != nil {
+			return nil, err
+		}
+	}
+	if l.file == "" {
+		return l, nil
+	}
+	l.fileName = l.file
+	if rel, err := filepath.Rel(dir, l.file); err == nil && !strings.HasPrefix(rel, "..") {
+		l.fileName = filepath.ToSlash(rel)
+	}
+	var err error
+	if l.fileValues, err = readFile(l.file); err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", l.file, err)
+	}
+	return l, nil
+}
+
+func findFile(dir string) (string, error) {
+	for _, p := range FilePaths {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		exists, err := fs.FileExists(full)
+		if err != nil || exists {
+			return full, err
+		}
+	}
+	return "", nil
+}
+
+// process populates c from all sources and checks that every key in the file
+// and flags corresponds to a field.
+func (l *loader) process(c *Config) error {
+	if err := envconfig.ProcessWith(context.Background(), c, l); err != nil {
+		return err
+	}
+	var unknown []string
+	for k := range l.fileValues {
+		if !l.used[k] {
+			unknown = append(unknown, strings.ToLower(k))
+		}
+	}
+	for k := range l.flags {
+		if !l.used[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	for k := range l.workflow {
+		if !l.used[k] {
+			unknown = append(unknown, strings.ToLower(k))
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Lookup implements envconfig.Lookuper. Empty environment variables are
+// treated as unset, because the action always sets every input, even
+// those left empty by the user.
+func (l *loader) Lookup(key string) (string, bool) {
+	l.used[key] = true
+	if v, ok := l.flags[key]; ok {
+		l.sources[key] = SourceFlag
+		return v, true
+	}
+	if v := l.workflow[key]; v != "" {
+		l.sources[key] = SourceWorkflow + ":" + l.workflowName
+		return v, true
+	}
+	if v := os.Getenv(key); v != "" {
+		l.sources[key] = SourceEnv
+		return v, true
+	}
+	if v, ok := l.fileValues[key]; ok {
+		l.sources[key] = SourceFile + ":" + l.fileName
+		return v, true
+	}
+	return "", false
+}
+
+// readFile reads a flat map of config values from an HCL or YAML file.
+// Keys are environment variable names, in upper or lower case.
+func readFile(path string) (map[string]string, error) {
+	var values map[string]string
+	var err error
+	switch ext := filepath.Ext(path); ext {
+	case ".hcl":
+		values, err = readHCL(path)
+	case ".yaml", ".yml":
+		values, err = readYAML(path)
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q; must be .hcl, .yaml, or .yml", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[strings.ToUpper(k)] = v
+	}
+	return out, nil
+}
+
+func readHCL(path string) (map[string]string, error) {
+	f, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	attrs, diags := f.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	out := make(map[string]string, len(attrs))
+	for name, attr := range attrs {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		s, err := convert.Convert(v, cty.String)
+		if err != nil || s.IsNull() {
+			return nil, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("%s must be a string, number, or bool", name),
+				Subject:  attr.Expr.Range().Ptr(),
+			}
+		}
+		out[name] = s.AsString()
+	}
+	return out, nil
+}
+
+func readYAML(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Decode nodes rather than values so that e.g. go_version: 1.20 isn't
+	// read as the number 1.2.
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(raw))
+	for k, n := range raw {
+		if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+			return nil, fmt.Errorf("line %d: %s must be a string, number, or bool", n.Line, k)
+		}
+		out[k] = n.Value
+	}
+	return out, nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+func TestLoader_process_ok(t *testing.T) {
+
+	const hclFile = `
+os           = "darwin"
+arch         = "arm64"
+reproducible = "report"
+debug        = true
+instructions = <<-EOT
+  echo hello
+  go build -o "$BIN_PATH"
+EOT
+`
+	const yamlFile = `
+os: darwin
+arch: arm64
+reproducible: report
+debug: true
+go_version: 1.20
+instructions: |
+  echo hello
+  go build -o "$BIN_PATH"
+`
+
+	type values struct {
+		OS, Arch, Reproducible, Instructions, GoVersion string
+		Debug                                           bool
+	}
+	get := func(c Config) values {
+		p := c.Parameters
+		return values{p.OS, p.Arch, c.Reproducible, p.Instructions, p.GoVersion, c.Debug}
+	}
+	const instructions = "echo hello\ngo build -o \"$BIN_PATH\"\n"
+
+	cases := []struct {
+		desc        string
+		file, data  string
+		env         map[string]string
+		flags       map[string]string
+		workflow    map[string]string
+		want        values
+		wantSources Sources
+	}{
+		{
+			"hcl only", "build.hcl", hclFile, nil, nil, nil,
+			values{"darwin", "arm64", "report", instructions, "", true},
+			Sources{"OS": "file:.release/build.hcl", "ARCH": "file:.release/build.hcl", "REPRODUCIBLE": "file:.release/build.hcl",
+				"DEBUG": "file:.release/build.hcl", "INSTRUCTIONS": "file:.release/build.hcl"},
+		},
+		{
+			"yaml only", "build.yaml", yamlFile, nil, nil, nil,
+			values{"darwin", "arm64", "report", instructions, "1.20", true},
+			Sources{"OS": "file:.release/build.yaml", "ARCH": "file:.release/build.yaml", "REPRODUCIBLE": "file:.release/build.yaml",
+				"DEBUG": "file:.release/build.yaml", "INSTRUCTIONS": "file:.release/build.yaml", "GO_VERSION": "file:.release/build.yaml"},
+		},
+		{
+			"env beats file", "build.hcl", `os = "darwin"`, map[string]string{"OS": "linux", "ARCH": ""}, nil, nil,
+			values{OS: "linux"},
+			Sources{"OS": "env"},
+		},
+		{
+			"flags beat env and file", "build.hcl", `os = "darwin"`, map[string]string{"OS": "linux"}, map[string]string{"os": "windows"}, nil,
+			values{OS: "windows"},
+			Sources{"OS": "flag"},
+		},
+		{
+			"workflow beats env and file", "build.hcl", `os = "darwin"`, map[string]string{"OS": "linux", "ARCH": "amd64"}, nil,
+			map[string]string{"OS": "freebsd", "ARCH": ""},
+			values{OS: "freebsd", Arch: "amd64"},
+			Sources{"OS": "workflow:.github/workflows/build.yml", "ARCH": "env"},
+		},
+		{
+			"flags beat workflow", "", "", nil, map[string]string{"OS": "windows"}, map[string]string{"OS": "freebsd"},
+			values{OS: "windows"},
+			Sources{"OS": "flag"},
+		},
+		{
+			"no file", "", "", map[string]string{"ARCH": "amd64"}, nil, nil,
+			values{Arch: "amd64"},
+			Sources{"ARCH": "env"},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			for _, k := range []string{"OS", "ARCH", "REPRODUCIBLE", "DEBUG", "INSTRUCTIONS", "GO_VERSION"} {
+				t.Setenv(k, c.env[k])
+			}
+			dir := tmp.Dir(t)
+			if c.file != "" {
+				writeConfigFile(t, dir, c.file, c.data)
+			}
+			l, err := newLoader(dir, WithFlags(c.flags), WithWorkflow(".github/workflows/build.yml", c.workflow))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Config
+			if err := l.process(&got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(get(got), c.want); diff != "" {
+				t.Errorf("values: %s", diff)
+			}
+			if diff := cmp.Diff(l.sources, c.wantSources); diff != "" {
+				t.Errorf("sources: %s", diff)
+			}
+		})
+	}
+}
+
+// TestLoader_process_goEnv checks Go's own variables set on the runner
+// aren't read as build parameters, since they'd change the default zip name.
+func TestLoader_process_goEnv(t *testing.T) {
+	t.Setenv("CGO_ENABLED", "0")
+	t.Setenv("GOAMD64", "v3")
+	t.Setenv("BUILD_GOARM", "7")
+	l, err := newLoader(tmp.Dir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err := l.process(&got); err != nil {
+		t.Fatal(err)
+	}
+	p := got.Parameters
+	if p.CGOEnabled != "" || p.GOAMD64 != "" || p.GOARM != "7" {
+		t.Errorf("got CGOEnabled %q, GOAMD64 %q, GOARM %q; want only GOARM 7", p.CGOEnabled, p.GOAMD64, p.GOARM)
+	}
+}
+
+func TestLoader_process_err(t *testing.T) {
+
+	cases := []struct {
+		desc, file, data string
+		flags, workflow  map[string]string
+		want             string
+	}{
+		{"unknown file key", "build.hcl", `oss = "linux"`, nil, nil, "unknown config keys: oss"},
+		{"unknown flag", "build.hcl", ``, map[string]string{"NOPE": "x"}, nil, "unknown config keys: NOPE"},
+		{"hcl list", "build.hcl", `os = ["linux"]`, nil, nil, "os must be a string, number, or bool"},
+		{"yaml map", "build.yaml", "os:\n  name: linux\n", nil, nil, "os must be a string, number, or bool"},
+		{"bad bool", "build.yaml", "debug: maybe\n", nil, nil, `parsing "maybe"`},
+		{"unknown workflow input", "build.hcl", ``, nil, map[string]string{"OSS": "linux"}, "unknown config keys: oss"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := tmp.Dir(t)
+			writeConfigFile(t, dir, c.file, c.data)
+			l, err := newLoader(dir, WithFlags(c.flags), WithWorkflow("build.yml", c.workflow))
+			if err == nil {
+				err = l.process(&Config{})
+			}
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("got error %v; want error containing %q", err, c.want)
+			}
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, dir, name, data string) {
+	t.Helper()
+	path := filepath.Join(dir, ".release", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package download provides an HTTP downloader that supports timeouts,
+// retries with exponential backoff, resuming partial downloads using
+// HTTP range requests, size limits, and periodic progress logging.
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/actions-go-build/internal/log"
+)
+
+// Downloader downloads files over HTTP. Use New to create one.
+type Downloader struct {
+	client *http.Client
+	log    log.Func
+	// attemptTimeout is the maximum time allowed for a single attempt.
+	// Zero means no per-attempt timeout (the context still applies).
+	attemptTimeout time.Duration
+	// retries is the number of times to retry after the first attempt fails
+	// with a retryable error.
+	retries int
+	// backoff is the time to wait before the first retry. It doubles for each
+	// subsequent retry, up to maxBackoff.
+	backoff, maxBackoff time.Duration
+	// maxSize is the maximum number of bytes we will download. Zero means
+	// no limit.
+	maxSize int64
+	// progressInterval is how often to log progress.
+	progressInterval time.Duration
+	// sleep is overridden in tests to avoid waiting.
+	sleep func(context.Context, time.Duration) error
+}
+
+// Option configures a Downloader.
+type Option func(*Downloader)
+
+// WithClient sets the HTTP client to use.
+func WithClient(c *http.Client) Option { return func(d *Downloader) { d.client = c } }
+
+// WithLogFunc sets the func used to log progress and retries.
+func WithLogFunc(f log.Func) Option { return func(d *Downloader) { d.log = f } }
+
+// WithAttemptTimeout sets the maximum duration of each individual attempt.
+func WithAttemptTimeout(t time.Duration) Option { return func(d *Downloader) { d.attemptTimeout = t } }
+
+// WithRetries sets the number of retries after the initial attempt.
+func WithRetries(n int) Option { return func(d *Downloader) { d.retries = n } }
+
+// WithBackoff sets the initial and maximum wait between retries.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(d *Downloader) { d.backoff, d.maxBackoff = initial, max }
+}
+
+// WithMaxSize sets the maximum number of bytes to download.
+func WithMaxSize(n int64) Option { return func(d *Downloader) { d.maxSize = n } }
+
+// WithProgressInterval sets how often progress is logged.
+func WithProgressInterval(i time.Duration) Option {
+	return func(d *Downloader) { d.progressInterval = i }
+}
+
+// New returns a Downloader with sensible defaults, modified by opts.
+func New(opts ...Option) *Downloader {
+	d := &Downloader{
+		client:           http.DefaultClient,
+		log:              log.Discard,
+		attemptTimeout:   10 * time.Minute,
+		retries:          4,
+		backoff:          time.Second,
+		maxBackoff:       30 * time.Second,
+		progressInterval: 5 * time.Second,
+		sleep:            sleepContext,
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// ErrTooLarge is returned when a download exceeds the configured maximum size.
+var ErrTooLarge = errors.New("download exceeds maximum size")
+
+// StatusError is returned when the server responds with an unexpected status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.URL, e.Status)
+}
+
+// retryable returns true for server errors and rate limiting.
+func (e *StatusError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// ToFile downloads url to path. If path already contains a partial download
+// from an earlier attempt, we try to resume it using a range request.
+func (d *Downloader) ToFile(ctx context.Context, url, path string) error {
+	return d.withRetries(ctx, url, func(ctx context.Context) error {
+		return d.toFileOnce(ctx, url, path)
+	})
+}
+
+// Bytes downloads url and returns its contents.
+func (d *Downloader) Bytes(ctx context.Context, url string) ([]byte, error) {
+	var data []byte
+	err := d.withRetries(ctx, url, func(ctx context.Context) error {
+		var err error
+		data, err = d.bytesOnce(ctx, url)
+		return err
+	})
+	return data, err
+}
+
+func (d *Downloader) withRetries(ctx context.Context, url string, attempt func(context.Context) error) error {
+	wait := d.backoff
+	for i := 0; ; i++ {
+		err := d.attempt(ctx, attempt)
+		if err == nil {
+			return nil
+		}
+		if i >= d.retries || !isRetryable(ctx, err) {
+			return err
+		}
+		d.log("Download of %s failed (attempt %d of %d): %s; retrying in %s", url, i+1, d.retries+1, err, wait)
+		if err := d.sleep(ctx, wait); err != nil {
+			return err
+		}
+		if wait *= 2; d.maxBackoff != 0 && wait > d.maxBackoff {
+			wait = d.maxBackoff
+		}
+	}
+}
+
+func (d *Downloader) attempt(ctx context.Context, attempt func(context.Context) error) error {
+	if d.attemptTimeout == 0 {
+		return attempt(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.attemptTimeout)
+	defer cancel()
+	return attempt(ctx)
+}
+
+// isRetryable decides if err is worth retrying. Errors caused by the parent
+// context being done, size limits, and client errors are never retried.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrTooLarge) {
+		return false
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		// Local filesystem errors won't be fixed by retrying.
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.retryable()
+	}
+	// Everything else is a connection or transfer error, including per-attempt timeouts.
+	return true
+}
+
+func (d *Downloader) get(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp, nil
+	}
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	_ = resp.Body.Close()
+	return nil, &StatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+}
+
+func (d *Downloader) toFileOnce(ctx context.Context, url, path string) (err error) {
+	offset, err := partialSize(path)
+	if err != nil {
+		return err
+	}
+	resp, err := d.get(ctx, url, offset)
+	var se *StatusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The partial file is no good to us, so start from scratch.
+		d.log("Unable to resume download of %s; starting again", url)
+		offset = 0
+		resp, err = d.get(ctx, url, offset)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { err = firstErr(err, resp.Body.Close()) }()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resp.StatusCode == http.StatusPartialContent && offset > 0 {
+		d.log("Resuming download of %s from byte %d", url, offset)
+		flags |= os.O_APPEND
+	} else {
+		// The server ignored our range request (or there was none), so start over.
+		offset = 0
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { err = firstErr(err, f.Close()) }()
+
+	return d.copy(ctx, url, f, resp, offset)
+}
+
+func (d *Downloader) bytesOnce(ctx context.Context, url string) (data []byte, err error) {
+	resp, err := d.get(ctx, url, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = firstErr(err, resp.Body.Close()) }()
+	buf := &sliceWriter{}
+	if err := d.copy(ctx, url, buf, resp, 0); err != nil {
+		return nil, err
+	}
+	return buf.b, nil
+}
+
+// copy copies the response body to w, enforcing the size limit and logging
+// progress as it goes. Offset is the number of bytes already downloaded.
+func (d *Downloader) copy(ctx context.Context, url string, w io.Writer, resp *http.Response, offset int64) error {
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	if d.maxSize > 0 && total > d.maxSize {
+		return fmt.Errorf("%w: %s is %d bytes; limit is %d", ErrTooLarge, url, total, d.maxSize)
+	}
+	body := io.Reader(resp.Body)
+	if d.maxSize > 0 {
+		// Read one byte more than allowed so we can detect overruns
+		// when the server didn't send a Content-Length.
+		body = io.LimitReader(body, d.maxSize-offset+1)
+	}
+	p := &progress{
+		log:      d.log,
+		url:      url,
+		done:     offset,
+		total:    total,
+		interval: d.progressInterval,
+		last:     time.Now(),
+	}
+	n, err := io.Copy(io.MultiWriter(w, p), body)
+	if err != nil {
+		return err
+	}
+	if d.maxSize > 0 && offset+n > d.maxSize {
+		return fmt.Errorf("%w: %s exceeds limit of %d bytes", ErrTooLarge, url, d.maxSize)
+	}
+	if total >= 0 && offset+n != total {
+		return fmt.Errorf("short read from %s: got %d of %d bytes", url, offset+n, total)
+	}
+	d.log("Downloaded %s (%s)", url, formatBytes(offset+n))
+	return ctx.Err()
+}
+
+// partialSize returns the size of any existing file at path, or zero if none exists.
+func partialSize(path string) (int64, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+type progress struct {
+	log         log.Func
+	url         string
+	done, total int64
+	interval    time.Duration
+	last        time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if p.interval > 0 && time.Since(p.last) >= p.interval {
+		p.last = time.Now()
+		if p.total > 0 {
+			p.log("Downloading %s: %s of %s (%d%%)", p.url, formatBytes(p.done), formatBytes(p.total), p.done*100/p.total)
+		} else {
+			p.log("Downloading %s: %s", p.url, formatBytes(p.done))
+		}
+	}
+	return len(b), nil
+}
+
+type sliceWriter struct{ b []byte }
+
+func (s *sliceWriter) Write(p []byte) (int, error) {
+	s.b = append(s.b, p...)
+	return len(p), nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
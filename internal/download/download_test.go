@@ -0,0 +1,233 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const payload = "the quick brown fox jumps over the lazy dog"
+
+func testDownloader(t *testing.T, opts ...Option) *Downloader {
+	t.Helper()
+	d := New(append([]Option{
+		WithLogFunc(t.Logf),
+		WithBackoff(time.Millisecond, time.Millisecond),
+	}, opts...)...)
+	d.sleep = func(context.Context, time.Duration) error { return nil }
+	return d
+}
+
+func TestDownloader_Bytes_ok(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, payload)
+	}))
+	defer s.Close()
+
+	got, err := testDownloader(t).Bytes(context.Background(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("got %q; want %q", got, payload)
+	}
+}
+
+func TestDownloader_Bytes_retries(t *testing.T) {
+
+	cases := []struct {
+		desc      string
+		failures  int32
+		status    int
+		retries   int
+		wantCalls int32
+		wantErr   string
+	}{
+		{
+			desc:      "recovers from 5xx",
+			failures:  2,
+			status:    http.StatusBadGateway,
+			retries:   2,
+			wantCalls: 3,
+		},
+		{
+			desc:      "gives up after retries",
+			failures:  5,
+			status:    http.StatusServiceUnavailable,
+			retries:   2,
+			wantCalls: 3,
+			wantErr:   "503 Service Unavailable",
+		},
+		{
+			desc:      "does not retry 4xx",
+			failures:  5,
+			status:    http.StatusNotFound,
+			retries:   2,
+			wantCalls: 1,
+			wantErr:   "404 Not Found",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			var calls int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) <= c.failures {
+					w.WriteHeader(c.status)
+					return
+				}
+				fmt.Fprint(w, payload)
+			}))
+			defer s.Close()
+
+			_, err := testDownloader(t, WithRetries(c.retries)).Bytes(context.Background(), s.URL)
+			if c.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+				t.Fatalf("got error %v; want it to contain %q", err, c.wantErr)
+			}
+			if calls != c.wantCalls {
+				t.Errorf("got %d calls; want %d", calls, c.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDownloader_ToFile_resume(t *testing.T) {
+	var gotRange string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "payload", time.Time{}, strings.NewReader(payload))
+	}))
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(payload[:10]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testDownloader(t).ToFile(context.Background(), s.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	if want := "bytes=10-"; gotRange != want {
+		t.Errorf("got Range header %q; want %q", gotRange, want)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("got %q; want %q", got, payload)
+	}
+}
+
+func TestDownloader_ToFile_rangeIgnored(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, payload)
+	}))
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testDownloader(t).ToFile(context.Background(), s.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("got %q; want %q", got, payload)
+	}
+}
+
+func TestDownloader_maxSize(t *testing.T) {
+
+	cases := []struct {
+		desc          string
+		contentLength bool
+	}{
+		{"with content length", true},
+		{"without content length", false},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			var calls int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if c.contentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+				} else {
+					w.(http.Flusher).Flush()
+				}
+				fmt.Fprint(w, payload)
+			}))
+			defer s.Close()
+
+			_, err := testDownloader(t, WithMaxSize(10)).Bytes(context.Background(), s.URL)
+			if !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("got error %v; want %v", err, ErrTooLarge)
+			}
+			if calls != 1 {
+				t.Errorf("got %d calls; want 1", calls)
+			}
+		})
+	}
+}
+
+func TestDownloader_attemptTimeout(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, payload)
+	}))
+	defer s.Close()
+
+	d := testDownloader(t, WithAttemptTimeout(50*time.Millisecond))
+	got, err := d.Bytes(context.Background(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("got %q; want %q", got, payload)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls; want 2", calls)
+	}
+}
+
+func TestDownloader_contextCancelled(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := testDownloader(t).Bytes(ctx, s.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v; want %v", err, context.Canceled)
+	}
+}
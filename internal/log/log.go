@@ -4,7 +4,11 @@
 package log
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -39,24 +43,123 @@ func IsInfo() bool {
 	return true
 }
 
+// Levels of log events, as shown in the JSON format.
+const (
+	LevelDebug   = slog.LevelDebug
+	LevelVerbose = slog.Level(-2)
+	LevelInfo    = slog.LevelInfo
+)
+
+// Formats that logs can be written in.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 var nothing = func(string, ...any) {}
 
-var Info, Verbose, Debug, Discard = func() (info, verbose, debug, discard Func) {
-	info, verbose, debug, discard = nothing, nothing, nothing, nothing
-	l := log.New(os.Stderr, "", 0)
-	if !IsTerm() {
-		l.SetFlags(log.LstdFlags)
+// Info, Verbose, and Debug log at their respective levels, if that level
+// is enabled by default. Discard logs nothing.
+var Info, Verbose, Debug, Discard Func
+
+// structured is the logger used in JSON format, and is nil in text format.
+var structured *slog.Logger
+
+// text is the logger used in text format.
+var text *log.Logger
+
+func init() {
+	if err := setFormat(FormatText, os.Stderr); err != nil {
+		panic(err)
 	}
+}
+
+// SetFormat sets the format of all subsequent logs to "text", the default,
+// or "json". Funcs returned before calling it keep the old format.
+func SetFormat(format string) error {
+	return setFormat(format, os.Stderr)
+}
+
+func setFormat(format string, w io.Writer) error {
+	switch format {
+	default:
+		return fmt.Errorf("unknown log format %q; want %q or %q", format, FormatText, FormatJSON)
+	case FormatText:
+		structured = nil
+		text = log.New(w, "", 0)
+		if !IsTerm() {
+			text.SetFlags(log.LstdFlags)
+		}
+	case FormatJSON:
+		structured = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       LevelDebug,
+			ReplaceAttr: replaceLevel,
+		}))
+	}
+	Info, Verbose, Debug, Discard = nothing, nothing, nothing, nothing
 	if IsInfo() {
-		info = l.Printf
+		Info = At(LevelInfo)
 	}
 	if IsVerbose() {
-		verbose = l.Printf
+		Verbose = At(LevelVerbose)
 	}
 	if IsDebug() {
-		debug = func(f string, a ...any) {
-			l.Printf("DEBUG: "+f, a...)
+		Debug = At(LevelDebug)
+		if structured == nil {
+			Debug = func(f string, a ...any) { text.Printf("DEBUG: "+f, a...) }
 		}
 	}
-	return
-}()
+	return nil
+}
+
+// IsStructured returns true if logs are written as JSON.
+func IsStructured() bool { return structured != nil }
+
+// At returns a Func that always logs at level. In text format the level
+// isn't shown.
+func At(level slog.Level) Func {
+	if structured == nil {
+		return text.Printf
+	}
+	l := structured
+	return func(f string, a ...any) {
+		a, kv := splitAttrs(a)
+		l.Log(context.Background(), level, fmt.Sprintf(f, a...), kv...)
+	}
+}
+
+// attrs are key-value pairs passed through a Func's arguments by With,
+// so that they survive being passed through wrappers like prefixers.
+type attrs []any
+
+// With returns a Func that adds the key-value pairs in kv to each event
+// logged by f in JSON format. In text format, f is returned unchanged.
+func With(f Func, kv ...any) Func {
+	if structured == nil || len(kv) == 0 {
+		return f
+	}
+	return func(format string, a ...any) {
+		f(format, append(a[:len(a):len(a)], attrs(kv))...)
+	}
+}
+
+// splitAttrs separates any attrs added by With from the format args in a.
+func splitAttrs(a []any) (args, kv []any) {
+	for _, v := range a {
+		if kvs, ok := v.(attrs); ok {
+			kv = append(kv, kvs...)
+			continue
+		}
+		args = append(args, v)
+	}
+	return args, kv
+}
+
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if l, ok := a.Value.Any().(slog.Level); ok && l == LevelVerbose {
+			a.Value = slog.StringValue("VERBOSE")
+		}
+	}
+	return a
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetFormat_json(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := setFormat(FormatJSON, buf); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = setFormat(FormatText, os.Stderr) })
+
+	prefixed := func(f string, a ...any) { At(LevelVerbose)("prefixed: "+f, a...) }
+	With(prefixed, "step", "zipping")("hello %s", "world")
+	With(At(LevelDebug), "a", 1)("%d%%", 100)
+
+	var got []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event map[string]any
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("line %q: %s", line, err)
+		}
+		delete(event, "time")
+		got = append(got, event)
+	}
+	want := []map[string]any{
+		{"level": "VERBOSE", "msg": "prefixed: hello world", "step": "zipping"},
+		{"level": "DEBUG", "msg": "100%", "a": float64(1)},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSetFormat_text(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := setFormat(FormatText, buf); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = setFormat(FormatText, os.Stderr) })
+
+	text.SetFlags(0)
+	With(At(LevelInfo), "step", "zipping")("hello %s", "world")
+	if got, want := buf.String(), "hello world\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if err := SetFormat("yaml"); err == nil {
+		t.Errorf("got nil error for unknown format")
+	}
+}
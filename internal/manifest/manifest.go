@@ -0,0 +1,203 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package manifest reads the repository-level products manifest, which declares
+// every product that can be built from a single (mono)repository.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// Paths are the places we look for a manifest, relative to the repository root,
+// in order of preference.
+var Paths = []string{".release/products.hcl", ".release/products.json"}
+
+// ErrNoManifest is returned by Find when there's no manifest in the repository.
+var ErrNoManifest = fmt.Errorf("no products manifest found at any of %s", strings.Join(Paths, ", "))
+
+// Manifest declares all the products in a repository.
+type Manifest struct {
+	// SharedPaths are repo-relative paths that every product depends on,
+	// e.g. a top-level go.mod or internal packages.
+	SharedPaths []string  `hcl:"shared_paths,optional" json:"shared_paths,omitempty"`
+	Products    []Product `hcl:"product,block" json:"products"`
+}
+
+// Product declares a single product. All fields except Name are optional.
+type Product struct {
+	Name string `hcl:"name,label" json:"name"`
+	// Dir is the product directory relative to the repository root.
+	// Defaults to the repository root.
+	Dir string `hcl:"dir,optional" json:"dir,omitempty"`
+	// ExecutableName defaults to the product name, minus any "-enterprise" suffix.
+	ExecutableName string `hcl:"executable_name,optional" json:"executable_name,omitempty"`
+	// VersionStrategy is a crt.VersionStrategy string.
+	VersionStrategy string `hcl:"version_strategy,optional" json:"version_strategy,omitempty"`
+	// Instructions defaults to the standard build instructions.
+	Instructions string `hcl:"instructions,optional" json:"instructions,omitempty"`
+	// Platforms is a list of "os/arch" pairs. If empty, the OS and ARCH
+	// from the environment are used.
+	Platforms []string `hcl:"platforms,optional" json:"platforms,omitempty"`
+	// SharedPaths are added to the manifest's SharedPaths for this product.
+	SharedPaths []string `hcl:"shared_paths,optional" json:"shared_paths,omitempty"`
+}
+
+// Platform is a target OS and architecture.
+type Platform struct{ OS, Arch string }
+
+func (p Platform) String() string { return p.OS + "/" + p.Arch }
+
+// Find returns the path of the manifest in rootDir, or ErrNoManifest.
+func Find(rootDir string) (string, error) {
+	for _, p := range Paths {
+		full := filepath.Join(rootDir, filepath.FromSlash(p))
+		exists, err := fs.FileExists(full)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return full, nil
+		}
+	}
+	return "", ErrNoManifest
+}
+
+// Read finds and loads the manifest in rootDir.
+func Read(rootDir string) (Manifest, error) {
+	p, err := Find(rootDir)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return Load(p)
+}
+
+// Load reads and validates the manifest at path, which must end in .hcl or .json.
+func Load(path string) (Manifest, error) {
+	var m Manifest
+	var err error
+	switch ext := filepath.Ext(path); ext {
+	case ".hcl":
+		err = loadHCL(path, &m)
+	case ".json":
+		err = loadJSON(path, &m)
+	default:
+		err = fmt.Errorf("unsupported manifest type %q; must be .hcl or .json", ext)
+	}
+	if err == nil {
+		err = m.Validate()
+	}
+	if err != nil {
+		return m, fmt.Errorf("reading products manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+func loadHCL(path string, m *Manifest) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// hclsimple picks the syntax from the file name, so pass the real one.
+	return hclsimple.Decode(filepath.Base(path), src, nil, m)
+}
+
+func loadJSON(path string, m *Manifest) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d := json.NewDecoder(f)
+	d.DisallowUnknownFields()
+	return d.Decode(m)
+}
+
+// Validate checks that product names are unique, that all paths stay inside
+// the repository, and that platforms are well-formed.
+func (m Manifest) Validate() error {
+	if len(m.Products) == 0 {
+		return errors.New("no products declared")
+	}
+	for _, p := range m.SharedPaths {
+		if err := checkRelPath(p); err != nil {
+			return fmt.Errorf("shared path %q: %w", p, err)
+		}
+	}
+	seen := map[string]bool{}
+	for _, p := range m.Products {
+		if p.Name == "" {
+			return errors.New("product with empty name")
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("product %q declared more than once", p.Name)
+		}
+		seen[p.Name] = true
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("product %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p Product) validate() error {
+	if p.Dir != "" {
+		if err := checkRelPath(p.Dir); err != nil {
+			return fmt.Errorf("dir %q: %w", p.Dir, err)
+		}
+	}
+	for _, s := range p.SharedPaths {
+		if err := checkRelPath(s); err != nil {
+			return fmt.Errorf("shared path %q: %w", s, err)
+		}
+	}
+	_, err := p.PlatformList()
+	return err
+}
+
+func checkRelPath(p string) error {
+	if path.IsAbs(p) || filepath.IsAbs(p) {
+		return errors.New("must be relative to the repository root")
+	}
+	if c := path.Clean(filepath.ToSlash(p)); c == ".." || strings.HasPrefix(c, "../") {
+		return errors.New("must not escape the repository root")
+	}
+	return nil
+}
+
+// PlatformList parses Platforms.
+func (p Product) PlatformList() ([]Platform, error) {
+	var out []Platform
+	for _, s := range p.Platforms {
+		os, arch, ok := strings.Cut(s, "/")
+		if !ok || os == "" || arch == "" || strings.Contains(arch, "/") {
+			return nil, fmt.Errorf("platform %q must be in the form os/arch", s)
+		}
+		out = append(out, Platform{OS: os, Arch: arch})
+	}
+	return out, nil
+}
+
+// Scope returns the repo-relative slash-separated paths this product's source
+// is drawn from: its own directory plus all shared paths. It returns nil if the
+// product lives in the repository root, since then the whole repository is in scope.
+func (m Manifest) Scope(p Product) []string {
+	dir := path.Clean(filepath.ToSlash(p.Dir))
+	if dir == "." {
+		return nil
+	}
+	scope := []string{dir}
+	for _, s := range append(append([]string{}, m.SharedPaths...), p.SharedPaths...) {
+		scope = append(scope, path.Clean(filepath.ToSlash(s)))
+	}
+	return scope
+}
@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+const testHCL = `
+shared_paths = ["go.mod", "internal"]
+
+product "lockbox" {
+  dir       = "cmd/lockbox"
+  platforms = ["linux/amd64", "darwin/arm64"]
+}
+
+product "lockbox-agent" {
+  dir              = "cmd/agent"
+  executable_name  = "lbagent"
+  version_strategy = "go-const:version.go"
+  instructions     = "go build -o $BIN_PATH ."
+  shared_paths     = ["proto"]
+}
+`
+
+const testJSON = `{
+  "shared_paths": ["go.mod", "internal"],
+  "products": [
+    {"name": "lockbox", "dir": "cmd/lockbox", "platforms": ["linux/amd64", "darwin/arm64"]},
+    {
+      "name": "lockbox-agent",
+      "dir": "cmd/agent",
+      "executable_name": "lbagent",
+      "version_strategy": "go-const:version.go",
+      "instructions": "go build -o $BIN_PATH .",
+      "shared_paths": ["proto"]
+    }
+  ]
+}`
+
+var testManifest = Manifest{
+	SharedPaths: []string{"go.mod", "internal"},
+	Products: []Product{
+		{Name: "lockbox", Dir: "cmd/lockbox", Platforms: []string{"linux/amd64", "darwin/arm64"}},
+		{
+			Name:            "lockbox-agent",
+			Dir:             "cmd/agent",
+			ExecutableName:  "lbagent",
+			VersionStrategy: "go-const:version.go",
+			Instructions:    "go build -o $BIN_PATH .",
+			SharedPaths:     []string{"proto"},
+		},
+	},
+}
+
+func TestRead_ok(t *testing.T) {
+	cases := []struct {
+		file, content string
+	}{
+		{"products.hcl", testHCL},
+		{"products.json", testJSON},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.file, func(t *testing.T) {
+			dir := writeManifest(t, c.file, c.content)
+			got, err := Read(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, testManifest); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRead_err(t *testing.T) {
+	cases := []struct {
+		desc, content, want string
+	}{
+		{"no products", `{"products": []}`, "no products declared"},
+		{"duplicate", `{"products": [{"name": "a"}, {"name": "a"}]}`, `product "a" declared more than once`},
+		{"escaping dir", `{"products": [{"name": "a", "dir": "../a"}]}`, "must not escape"},
+		{"absolute shared path", `{"shared_paths": ["/etc"], "products": [{"name": "a"}]}`, "must be relative"},
+		{"bad platform", `{"products": [{"name": "a", "platforms": ["linux"]}]}`, "must be in the form os/arch"},
+		{"unknown field", `{"products": [{"name": "a", "bin": "b"}]}`, `unknown field "bin"`},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeManifest(t, "products.json", c.content)
+			_, err := Read(dir)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("got error %v; want error containing %q", err, c.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := Read(tmp.Dir(t)); !errors.Is(err, ErrNoManifest) {
+			t.Fatalf("got error %v; want ErrNoManifest", err)
+		}
+	})
+}
+
+func TestManifest_Scope(t *testing.T) {
+	cases := []struct {
+		product Product
+		want    []string
+	}{
+		{Product{Name: "root"}, nil},
+		{Product{Name: "root", Dir: "."}, nil},
+		{Product{Name: "sub", Dir: "cmd/lockbox/"}, []string{"cmd/lockbox", "go.mod", "internal"}},
+		{Product{Name: "extra", Dir: "cmd/agent", SharedPaths: []string{"proto"}}, []string{"cmd/agent", "go.mod", "internal", "proto"}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.product.Name, func(t *testing.T) {
+			got := testManifest.Scope(c.product)
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := tmp.Dir(t)
+	if err := os.MkdirAll(filepath.Join(dir, ".release"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".release", name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
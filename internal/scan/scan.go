@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package scan finds occurrences of strings inside arbitrary (usually binary) files.
+package scan
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+)
+
+// Hit records every offset at which Needle was found.
+type Hit struct {
+	Needle  string
+	Offsets []int64
+}
+
+// maxOffsets caps the number of offsets recorded per needle, so that a
+// path that's embedded thousands of times doesn't produce a huge result.
+const maxOffsets = 100
+
+const chunkSize = 1 << 16
+
+// File scans the file at path for needles. See Reader.
+func File(path string, needles ...string) ([]Hit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := Reader(f, needles...)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return hits, err
+}
+
+// Reader scans r for needles, returning a Hit for each needle found at least once,
+// sorted by needle. Empty and duplicate needles are ignored.
+func Reader(r io.Reader, needles ...string) ([]Hit, error) {
+	needles = uniqueNonEmpty(needles)
+	if len(needles) == 0 {
+		return nil, nil
+	}
+	longest := 0
+	for _, n := range needles {
+		if len(n) > longest {
+			longest = len(n)
+		}
+	}
+
+	offsets := map[string][]int64{}
+	// window holds the tail of the previous chunk followed by the current chunk,
+	// so that needles spanning chunk boundaries are found. base is the offset in
+	// the stream of window[0].
+	var window []byte
+	var base int64
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			window = append(window, buf[:n]...)
+			// Only report matches that start before keep, the rest will be found
+			// again next time round, once we can see the whole needle.
+			keep := len(window) - (longest - 1)
+			if keep < 0 {
+				keep = 0
+			}
+			for _, needle := range needles {
+				find(window, needle, base, keep, offsets)
+			}
+			base += int64(keep)
+			window = append(window[:0], window[keep:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Anything left in the window is shorter than the longest needle,
+	// but may still contain the shorter ones.
+	for _, needle := range needles {
+		find(window, needle, base, len(window), offsets)
+	}
+
+	var hits []Hit
+	for _, needle := range needles {
+		if o := offsets[needle]; len(o) > 0 {
+			hits = append(hits, Hit{Needle: needle, Offsets: o})
+		}
+	}
+	return hits, nil
+}
+
+// find records offsets of needle in window that start before limit.
+func find(window []byte, needle string, base int64, limit int, offsets map[string][]int64) {
+	nb := []byte(needle)
+	for i := 0; i < limit; {
+		j := bytes.Index(window[i:], nb)
+		if j < 0 || i+j >= limit {
+			return
+		}
+		if len(offsets[needle]) < maxOffsets {
+			offsets[needle] = append(offsets[needle], base+int64(i+j))
+		}
+		i += j + 1
+	}
+}
+
+func uniqueNonEmpty(ss []string) []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, s := range ss {
+		if _, ok := seen[s]; ok || s == "" {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
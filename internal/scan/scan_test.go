@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package scan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader(t *testing.T) {
+	// Place needles either side of, and spanning, a chunk boundary.
+	padding := strings.Repeat("x", chunkSize-3)
+	input := "/home/a" + padding + "/home/b" + padding + "/tmp"
+
+	got, err := Reader(strings.NewReader(input), "/tmp", "/home/b", "/home/a", "", "/tmp", "/nowhere")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Hit{
+		{Needle: "/home/a", Offsets: []int64{0}},
+		{Needle: "/home/b", Offsets: []int64{int64(7 + len(padding))}},
+		{Needle: "/tmp", Offsets: []int64{int64(14 + 2*len(padding))}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
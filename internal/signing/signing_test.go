@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKey(t *testing.T, key crypto.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSignVerify(t *testing.T) {
+	_, want, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ReadPrivateKey(writeKey(t, want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"Product": "lockbox"}`)
+	sig := Sign(key, data)
+	if err := Verify(PublicKey(key), data, sig); err != nil {
+		t.Errorf("verifying signature: %s", err)
+	}
+	if err := Verify(PublicKey(key), []byte(`{"Product": "lockb0x"}`), sig); err == nil {
+		t.Errorf("got nil error verifying tampered data")
+	}
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	if err := Verify(PublicKey(other), data, sig); err == nil {
+		t.Errorf("got nil error verifying with the wrong key")
+	}
+}
+
+func TestReadPrivateKey_invalid(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for desc, path := range map[string]string{
+		"ecdsa key": writeKey(t, ecKey),
+		"not PEM":   notPEM,
+	} {
+		if _, err := ReadPrivateKey(path); err == nil {
+			t.Errorf("%s: got nil error", desc)
+		}
+	}
+}
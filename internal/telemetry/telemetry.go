@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package telemetry sets up OpenTelemetry tracing, configured by the standard
+// OTEL_* environment variables.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// FileExporterPathEnv names the file that spans are written to, as JSON,
+// when OTEL_TRACES_EXPORTER is "file". This isn't a standard variable; the
+// file exporter exists so traces can be checked without a collector.
+const FileExporterPathEnv = "OTEL_EXPORTER_FILE_PATH"
+
+// Shutdown flushes any buffered spans and stops exporting.
+type Shutdown func(context.Context) error
+
+// Init installs a global tracer provider, if tracing is enabled by the
+// environment, and returns a func to shut it down. Tracing is enabled if
+// OTEL_TRACES_EXPORTER is "otlp" or "file", or if it's unset and an OTLP
+// endpoint is set. OTEL_SDK_DISABLED=true disables it. If there's an error,
+// tracing isn't enabled, but the returned func can still be called.
+func Init(ctx context.Context, serviceVersion string) (Shutdown, error) {
+	noop := func(context.Context) error { return nil }
+	exporter, err := newExporter(ctx)
+	if err != nil || exporter == nil {
+		return noop, err
+	}
+	// Attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME
+	// take precedence over our defaults.
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			attribute.String("service.name", "actions-go-build"),
+			attribute.String("service.version", serviceVersion),
+		),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("creating trace resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// newExporter returns the exporter configured by the environment, or nil
+// if tracing isn't enabled.
+func newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil, nil
+	}
+	kind := os.Getenv("OTEL_TRACES_EXPORTER")
+	if kind == "" && (os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "") {
+		kind = "otlp"
+	}
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		// The exporter reads the OTEL_EXPORTER_OTLP_* variables itself.
+		return otlptracehttp.New(ctx)
+	case "file":
+		path := os.Getenv(FileExporterPathEnv)
+		if path == "" {
+			return nil, fmt.Errorf("OTEL_TRACES_EXPORTER=file requires %s", FileExporterPathEnv)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &fileExporter{SpanExporter: e, f: f}, nil
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER=%q not supported; use otlp, file, or none", kind)
+	}
+}
+
+// fileExporter closes its file on shutdown.
+type fileExporter struct {
+	sdktrace.SpanExporter
+	f *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
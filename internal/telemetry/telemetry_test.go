@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package telemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestInit_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	t.Setenv("OTEL_TRACES_EXPORTER", "file")
+	t.Setenv(FileExporterPathEnv, path)
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=test")
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	shutdown, err := Init(context.Background(), "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"Name":"test-span"`, `"Value":"actions-go-build"`, `"Value":"1.2.3"`, `"Value":"test"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported spans do not contain %s:\n%s", want, data)
+		}
+	}
+}
+
+func TestInit_error(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	prev := otel.GetTracerProvider()
+
+	shutdown, err := Init(context.Background(), "1.2.3")
+	if err == nil {
+		t.Fatal("got no error; want unsupported exporter error")
+	}
+	if otel.GetTracerProvider() != prev {
+		t.Error("tracer provider was replaced despite the error")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewExporter(t *testing.T) {
+
+	cases := []struct {
+		desc    string
+		env     map[string]string
+		want    bool
+		wantErr string
+	}{
+		{"unset", nil, false, ""},
+		{"none", map[string]string{"OTEL_TRACES_EXPORTER": "none", "OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, false, ""},
+		{"endpoint implies otlp", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, true, ""},
+		{"disabled", map[string]string{"OTEL_SDK_DISABLED": "true", "OTEL_TRACES_EXPORTER": "otlp"}, false, ""},
+		{"file without path", map[string]string{"OTEL_TRACES_EXPORTER": "file"}, false, FileExporterPathEnv},
+		{"unknown", map[string]string{"OTEL_TRACES_EXPORTER": "zipkin"}, false, "not supported"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			for _, k := range []string{"OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", FileExporterPathEnv} {
+				t.Setenv(k, c.env[k])
+			}
+			got, err := newExporter(context.Background())
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("got error %v; want error containing %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got != nil) != c.want {
+				t.Errorf("got exporter %v; want exporter: %t", got, c.want)
+			}
+		})
+	}
+}
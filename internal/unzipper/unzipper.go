@@ -5,74 +5,369 @@ package unzipper
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/actions-go-build/internal/log"
 )
 
+// Unzipper extracts zip files that may come from untrusted sources.
+// It refuses to write outside of the destination directory, limits the
+// total size and number of entries extracted, and normalises permissions.
 type Unzipper struct {
-	log log.Func
+	log      log.Func
+	limits   Limits
+	symlinks SymlinkPolicy
 }
 
-func New(logFunc log.Func) *Unzipper {
-	return &Unzipper{log: logFunc}
+// Limits protect against zip bombs. A zero value for any field means no limit.
+type Limits struct {
+	// MaxTotalBytes is the maximum number of uncompressed bytes extracted.
+	MaxTotalBytes int64
+	// MaxEntries is the maximum number of entries in the archive.
+	MaxEntries int
+	// MaxRatio is the maximum uncompressed to compressed size ratio of any
+	// single entry. It only applies to entries of at least ratioThreshold bytes,
+	// since small files can legitimately compress very well.
+	MaxRatio uint64
 }
 
-func (uz *Unzipper) Unzip(file, dest string) error {
+// ratioThreshold is the uncompressed size above which MaxRatio is enforced.
+const ratioThreshold = 1 << 20
+
+// DefaultLimits are generous enough for any source archive we expect to see.
+var DefaultLimits = Limits{
+	MaxTotalBytes: 4 << 30,
+	MaxEntries:    200000,
+	MaxRatio:      100,
+}
+
+// SymlinkPolicy determines what happens to symlink entries.
+type SymlinkPolicy int
+
+const (
+	// SymlinksConfine allows relative symlinks whose targets stay inside
+	// the destination directory.
+	SymlinksConfine SymlinkPolicy = iota
+	// SymlinksReject fails extraction if any symlink entry is present.
+	SymlinksReject
+)
+
+// Option configures an Unzipper.
+type Option func(*Unzipper)
+
+// WithLimits sets the limits applied during extraction.
+func WithLimits(l Limits) Option { return func(uz *Unzipper) { uz.limits = l } }
+
+// WithSymlinkPolicy sets how symlink entries are handled.
+func WithSymlinkPolicy(p SymlinkPolicy) Option { return func(uz *Unzipper) { uz.symlinks = p } }
+
+func New(logFunc log.Func, opts ...Option) *Unzipper {
+	uz := &Unzipper{log: logFunc, limits: DefaultLimits}
+	for _, o := range opts {
+		o(uz)
+	}
+	return uz
+}
+
+var (
+	ErrIllegalPath      = errors.New("path escapes destination directory")
+	ErrAbsolutePath     = errors.New("absolute path")
+	ErrSymlink          = errors.New("symlinks not allowed")
+	ErrIllegalSymlink   = errors.New("symlink target escapes destination directory")
+	ErrDuplicate        = errors.New("duplicate entry")
+	ErrTooManyEntries   = errors.New("too many entries")
+	ErrTooLarge         = errors.New("total uncompressed size too large")
+	ErrCompressionRatio = errors.New("compression ratio too high")
+)
+
+// EntryError is returned when a specific entry in the archive is rejected.
+// Use errors.Is to check the reason against the Err... variables above.
+type EntryError struct {
+	// Entry is the name of the entry as recorded in the archive.
+	Entry string
+	Err   error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("zip entry %q rejected: %s", e.Entry, e.Err)
+}
+
+func (e *EntryError) Unwrap() error { return e.Err }
+
+func entryErr(f *zip.File, err error) error { return &EntryError{Entry: f.Name, Err: err} }
+
+func (uz *Unzipper) Unzip(file, dest string) (err error) {
 	r, err := zip.OpenReader(file)
 	if err != nil {
 		return err
 	}
-	var closeErr error
-	defer func() { closeErr = r.Close() }()
-	for _, f := range r.File {
-		target := filepath.Join(dest, f.Name)
-		// Prevent directory traversal.
-		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path %q", target)
+	defer func() {
+		if closeErr := r.Close(); err == nil {
+			err = closeErr
 		}
-		if err := uz.unzipFile(target, f); err != nil {
+	}()
+	if max := uz.limits.MaxEntries; max != 0 && len(r.File) > max {
+		return fmt.Errorf("%w: archive has %d entries; limit is %d", ErrTooManyEntries, len(r.File), max)
+	}
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	// Resolve dest so that checks against symlink targets compare like with like.
+	if dest, err = filepath.EvalSymlinks(dest); err != nil {
+		return err
+	}
+	x := &extraction{Unzipper: uz, dest: dest}
+	for _, f := range r.File {
+		if err := x.entry(f); err != nil {
 			return err
 		}
 	}
-	return closeErr
+	return nil
+}
+
+// extraction holds the state of a single call to Unzip.
+type extraction struct {
+	*Unzipper
+	dest    string
+	written int64
+}
+
+func (x *extraction) entry(f *zip.File) error {
+	target, err := x.targetPath(f.Name)
+	if err != nil {
+		return entryErr(f, err)
+	}
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return x.mkdir(f, target)
+	case mode&os.ModeSymlink != 0:
+		return x.symlink(f, target)
+	case mode.IsRegular():
+		return x.file(f, target)
+	default:
+		return entryErr(f, fmt.Errorf("unsupported file mode %s", mode))
+	}
+}
+
+// targetPath returns the absolute path that name should be extracted to.
+func (x *extraction) targetPath(name string) (string, error) {
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", ErrAbsolutePath
+	}
+	target := filepath.Join(x.dest, filepath.FromSlash(name))
+	if !x.within(target) {
+		return "", ErrIllegalPath
+	}
+	return target, nil
+}
+
+func (x *extraction) within(p string) bool {
+	return p == x.dest || strings.HasPrefix(p, x.dest+string(os.PathSeparator))
+}
+
+// checkParent ensures that the real location of target's parent directory,
+// after following any symlinks extracted earlier, is inside dest, and that
+// target doesn't exist yet. It returns that real location.
+func (x *extraction) checkParent(f *zip.File, target string) (string, error) {
+	real, err := x.realDir(f, filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return "", entryErr(f, ErrDuplicate)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return real, nil
 }
 
-func (uz *Unzipper) unzipFile(target string, f *zip.File) error {
-	if f.FileInfo().IsDir() {
-		return os.MkdirAll(target, f.Mode())
+func (x *extraction) mkdir(f *zip.File, target string) error {
+	_, err := x.realDir(f, target)
+	return err
+}
+
+// realDir creates dir, which must be inside dest, one component at a time.
+// Each component is checked before anything is created: symlinks extracted
+// earlier are followed, and must resolve to a directory inside dest. It
+// returns the real location of dir.
+func (x *extraction) realDir(f *zip.File, dir string) (string, error) {
+	rel, err := filepath.Rel(x.dest, dir)
+	if err != nil {
+		return "", err
 	}
+	real := x.dest
+	if rel == "." {
+		return real, nil
+	}
+	for _, name := range strings.Split(rel, string(os.PathSeparator)) {
+		next := filepath.Join(real, name)
+		fi, err := os.Lstat(next)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if err := os.Mkdir(next, 0755); err != nil {
+				return "", err
+			}
+		case err != nil:
+			return "", err
+		case fi.Mode()&os.ModeSymlink != 0:
+			if next, err = filepath.EvalSymlinks(next); err != nil {
+				return "", entryErr(f, err)
+			}
+			if !x.within(next) {
+				return "", entryErr(f, ErrIllegalPath)
+			}
+			if fi, err = os.Stat(next); err != nil {
+				return "", err
+			}
+			fallthrough
+		default:
+			if !fi.IsDir() {
+				return "", entryErr(f, fmt.Errorf("%s is not a directory", next))
+			}
+		}
+		real = next
+	}
+	return real, nil
+}
 
-	uz.log("Extracting file: %s", target)
-	if err := os.MkdirAll(filepath.Dir(target), f.Mode()); err != nil {
+func (x *extraction) symlink(f *zip.File, target string) error {
+	if x.symlinks == SymlinksReject {
+		return entryErr(f, ErrSymlink)
+	}
+	realParent, err := x.checkParent(f, target)
+	if err != nil {
 		return err
 	}
+	linkTarget, err := x.readSmall(f)
+	if err != nil {
+		return entryErr(f, err)
+	}
+	if !x.linkTargetWithin(realParent, linkTarget) {
+		return entryErr(f, ErrIllegalSymlink)
+	}
+	x.log("Creating symlink: %s -> %s", target, linkTarget)
+	return os.Symlink(linkTarget, target)
+}
 
+// linkTargetWithin reports whether linkTarget, relative to the real directory
+// the link is in, stays inside dest. The target may only go up at its start,
+// because the OS resolves each component before applying a later "..", so
+// "x/.." isn't the same as "." if x is a symlink, which may be extracted
+// later. Once it has gone up, the target can only go down into dest, even if
+// it goes through other symlinks, since they're checked in the same way.
+func (x *extraction) linkTargetWithin(realParent, linkTarget string) bool {
+	if linkTarget == "" || filepath.IsAbs(linkTarget) || path.IsAbs(linkTarget) || filepath.VolumeName(linkTarget) != "" {
+		return false
+	}
+	resolved, down := realParent, false
+	for _, name := range strings.Split(filepath.ToSlash(linkTarget), "/") {
+		switch {
+		case name == "" || name == ".":
+		case name == "..":
+			if down {
+				return false
+			}
+			resolved = filepath.Dir(resolved)
+		default:
+			down = true
+			resolved = filepath.Join(resolved, name)
+		}
+	}
+	return x.within(resolved)
+}
+
+// readSmall reads a symlink entry's contents, which is the link target.
+func (x *extraction) readSmall(f *zip.File) (string, error) {
+	const maxLinkTarget = 4096
+	if f.UncompressedSize64 > maxLinkTarget {
+		return "", fmt.Errorf("symlink target too long")
+	}
 	rc, err := f.Open()
 	if err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(io.LimitReader(rc, maxLinkTarget))
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+	return string(b), err
+}
+
+func (x *extraction) file(f *zip.File, target string) error {
+	if err := x.checkLimits(f); err != nil {
 		return err
 	}
-	var closeErr error
-	defer func() { closeErr = rc.Close() }()
-	if err := uz.writeFile(target, rc); err != nil {
+	if _, err := x.checkParent(f, target); err != nil {
 		return err
 	}
-	return closeErr
+	x.log("Extracting file: %s", target)
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	n, err := x.writeFile(target, normalisedFileMode(f.Mode()), rc, int64(f.UncompressedSize64))
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+	x.written += n
+	if errors.Is(err, ErrTooLarge) {
+		return entryErr(f, err)
+	}
+	return err
+}
+
+func (x *extraction) checkLimits(f *zip.File) error {
+	size := f.UncompressedSize64
+	if max := x.limits.MaxTotalBytes; max != 0 && x.written+int64(size) > max {
+		return entryErr(f, fmt.Errorf("%w: limit is %d bytes", ErrTooLarge, max))
+	}
+	if max := x.limits.MaxRatio; max != 0 && size >= ratioThreshold {
+		compressed := f.CompressedSize64
+		if compressed == 0 || size/compressed > max {
+			return entryErr(f, fmt.Errorf("%w: %d bytes compressed to %d; limit is %d:1", ErrCompressionRatio, size, compressed, max))
+		}
+	}
+	return nil
 }
 
-func (uz *Unzipper) writeFile(target string, r io.Reader) error {
-	t, err := os.Create(target)
+// writeFile writes at most declaredSize bytes from r to target, returning
+// an error if r contains more than that, because the zip header lied.
+func (x *extraction) writeFile(target string, mode os.FileMode, r io.Reader, declaredSize int64) (n int64, err error) {
+	t, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	var closeErr error
-	defer func() { closeErr = t.Close() }()
-	if _, err := io.Copy(t, r); err != nil {
-		return err
+	defer func() {
+		if closeErr := t.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	n, err = io.Copy(t, io.LimitReader(r, declaredSize+1))
+	if err != nil {
+		return n, err
+	}
+	if n > declaredSize {
+		return n, fmt.Errorf("%w: entry larger than declared size %d", ErrTooLarge, declaredSize)
+	}
+	return n, nil
+}
+
+// normalisedFileMode discards everything but the executable bit from the
+// mode recorded in the zip, so we never create setuid or world-writable files.
+func normalisedFileMode(m os.FileMode) os.FileMode {
+	if m&0111 != 0 {
+		return 0755
 	}
-	return closeErr
+	return 0644
 }
@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package unzipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	name, contents string
+	mode           os.FileMode
+}
+
+func file(name, contents string) entry  { return entry{name, contents, 0644} }
+func symlink(name, target string) entry { return entry{name, target, os.ModeSymlink | 0777} }
+func dir(name string) entry             { return entry{name + "/", "", os.ModeDir | 0755} }
+
+func writeTestZip(t *testing.T, entries ...entry) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		h.SetMode(e.mode)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnzipper_Unzip_ok(t *testing.T) {
+	zipFile := writeTestZip(t,
+		dir("repo"),
+		file("repo/main.go", "package main"),
+		entry{"repo/run.sh", "#!/bin/sh", 04777},
+		symlink("repo/link.go", "main.go"),
+		symlink("repo/sub/up", "../main.go"),
+	)
+	dest := t.TempDir()
+	if err := New(t.Logf).Unzip(zipFile, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "repo", "sub", "up"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "package main" {
+		t.Errorf("got %q via symlink; want %q", got, "package main")
+	}
+
+	for name, want := range map[string]os.FileMode{
+		"repo/main.go": 0644,
+		"repo/run.sh":  0755,
+	} {
+		fi, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode(); got != want {
+			t.Errorf("%s: got mode %s; want %s", name, got, want)
+		}
+	}
+}
+
+func TestUnzipper_Unzip_err(t *testing.T) {
+
+	big := strings.Repeat("a", 2*ratioThreshold)
+
+	cases := []struct {
+		desc      string
+		entries   []entry
+		opts      []Option
+		wantEntry string
+		wantErr   error
+	}{
+		{
+			desc:      "traversal",
+			entries:   []entry{file("../evil", "x")},
+			wantEntry: "../evil",
+			wantErr:   ErrIllegalPath,
+		},
+		{
+			desc:      "absolute",
+			entries:   []entry{file("/etc/evil", "x")},
+			wantEntry: "/etc/evil",
+			wantErr:   ErrAbsolutePath,
+		},
+		{
+			desc:      "symlink rejected",
+			entries:   []entry{symlink("link", "target")},
+			opts:      []Option{WithSymlinkPolicy(SymlinksReject)},
+			wantEntry: "link",
+			wantErr:   ErrSymlink,
+		},
+		{
+			desc:      "symlink escapes",
+			entries:   []entry{symlink("link", "../../etc/passwd")},
+			wantEntry: "link",
+			wantErr:   ErrIllegalSymlink,
+		},
+		{
+			desc:      "absolute symlink",
+			entries:   []entry{symlink("link", "/etc/passwd")},
+			wantEntry: "link",
+			wantErr:   ErrIllegalSymlink,
+		},
+		{
+			desc: "symlink escapes via earlier symlink",
+			entries: []entry{
+				symlink("a", "."),
+				symlink("a/link", "../outside"),
+			},
+			wantEntry: "a/link",
+			wantErr:   ErrIllegalSymlink,
+		},
+		{
+			desc: "symlink escapes via chained symlinks",
+			entries: []entry{
+				symlink("x", "."),
+				symlink("y", "x/.."),
+				dir("y/escaped"),
+			},
+			wantEntry: "y",
+			wantErr:   ErrIllegalSymlink,
+		},
+		{
+			desc: "symlink escapes via later symlink",
+			entries: []entry{
+				symlink("y", "x/.."),
+				symlink("x", "."),
+			},
+			wantEntry: "y",
+			wantErr:   ErrIllegalSymlink,
+		},
+		{
+			desc:      "duplicate",
+			entries:   []entry{file("a", "x"), file("a", "y")},
+			wantEntry: "a",
+			wantErr:   ErrDuplicate,
+		},
+		{
+			desc:      "total size",
+			entries:   []entry{file("a", "12345"), file("b", "12345")},
+			opts:      []Option{WithLimits(Limits{MaxTotalBytes: 8})},
+			wantEntry: "b",
+			wantErr:   ErrTooLarge,
+		},
+		{
+			desc:    "entry count",
+			entries: []entry{file("a", "x"), file("b", "y")},
+			opts:    []Option{WithLimits(Limits{MaxEntries: 1})},
+			wantErr: ErrTooManyEntries,
+		},
+		{
+			desc:      "compression ratio",
+			entries:   []entry{file("bomb", big)},
+			opts:      []Option{WithLimits(Limits{MaxRatio: 10})},
+			wantEntry: "bomb",
+			wantErr:   ErrCompressionRatio,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			zipFile := writeTestZip(t, c.entries...)
+			err := New(t.Logf, c.opts...).Unzip(zipFile, t.TempDir())
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("got error %v; want %v", err, c.wantErr)
+			}
+			if c.wantEntry == "" {
+				return
+			}
+			var ee *EntryError
+			if !errors.As(err, &ee) {
+				t.Fatalf("got %T; want *EntryError", err)
+			}
+			if ee.Entry != c.wantEntry {
+				t.Errorf("got entry %q; want %q", ee.Entry, c.wantEntry)
+			}
+		})
+	}
+}
+
+func TestUnzipper_Unzip_noWritesOutsideDest(t *testing.T) {
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A symlink that escapes dest, which wasn't extracted from the archive.
+	if err := os.Symlink("..", filepath.Join(dest, "out")); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []entry{dir("out/escaped"), file("out/escaped/evil", "x")} {
+		zipFile := writeTestZip(t, e)
+		if err := New(t.Logf).Unzip(zipFile, dest); !errors.Is(err, ErrIllegalPath) {
+			t.Errorf("%s: got error %v; want %v", e.name, err, ErrIllegalPath)
+		}
+		if _, err := os.Lstat(filepath.Join(parent, "escaped")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: created a directory outside dest", e.name)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package workflow reads the inputs passed to this action by a step in a GitHub
+// Actions workflow file, so that a CI build can be reproduced locally.
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Step is a step in a workflow that uses this action.
+type Step struct {
+	// Job is the ID of the job the step belongs to.
+	Job string
+	// Name is the step's id, name, or index, whichever is available first.
+	Name string
+	// Inputs are the step's evaluated with: inputs, keyed by input name.
+	Inputs map[string]string
+	// Root is the directory that relative paths in the workflow are
+	// resolved against, i.e. the repository root.
+	Root string
+}
+
+// WorkDir is the absolute path of the step's work_dir input.
+func (s Step) WorkDir() string {
+	return filepath.Join(s.Root, filepath.FromSlash(s.Inputs["work_dir"]))
+}
+
+// inputEnvNames are the environment variables for the inputs whose names
+// aren't simply upper-cased, to avoid reading Go's and the C toolchain's own
+// variables.
+var inputEnvNames = map[string]string{
+	"cgo_enabled": "BUILD_CGO_ENABLED",
+	"goarm":       "BUILD_GOARM",
+	"goamd64":     "BUILD_GOAMD64",
+	"cc":          "BUILD_CC",
+	"cxx":         "BUILD_CXX",
+	"zig":         "BUILD_ZIG",
+	"sysroot":     "BUILD_SYSROOT",
+	"cgo_cflags":  "BUILD_CGO_CFLAGS",
+}
+
+// Env returns the inputs as the environment variables the action passes to
+// the config command, i.e. with upper-cased names, apart from those in
+// inputEnvNames. The work_dir input is omitted, because it's not config but
+// where the config is read.
+func (s Step) Env() map[string]string {
+	out := make(map[string]string, len(s.Inputs))
+	for k, v := range s.Inputs {
+		switch name, ok := inputEnvNames[k]; {
+		case ok:
+			out[name] = v
+		case k != "work_dir":
+			out[strings.ToUpper(k)] = v
+		}
+	}
+	return out
+}
+
+type workflowFile struct {
+	Jobs map[string]job `yaml:"jobs"`
+}
+
+type job struct {
+	Uses     string `yaml:"uses"`
+	Strategy struct {
+		Matrix yaml.Node `yaml:"matrix"`
+	} `yaml:"strategy"`
+	Steps []step `yaml:"steps"`
+}
+
+type step struct {
+	ID   string               `yaml:"id"`
+	Name string               `yaml:"name"`
+	Uses string               `yaml:"uses"`
+	With map[string]yaml.Node `yaml:"with"`
+}
+
+// Read reads the workflow file at path and returns the evaluated inputs of the
+// step identified by ref, which is either a job ID, or a job ID and a step
+// id or name separated by a slash. If only a job ID is given, the job must
+// have exactly one step that uses hashicorp/actions-go-build.
+//
+// Expressions in the inputs are evaluated using matrix, and may only refer
+// to matrix values or literals. If the job's matrix lists include entries,
+// the first one that shares a key with matrix and agrees on all shared keys
+// provides any values not in matrix. Unknown matrix values are empty, as they
+// are in GitHub Actions.
+func Read(path, ref string, matrix map[string]string) (Step, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Step{}, err
+	}
+	var wf workflowFile
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return Step{}, fmt.Errorf("parsing workflow %s: %w", path, err)
+	}
+	jobID, stepRef, _ := strings.Cut(ref, "/")
+	j, ok := wf.Jobs[jobID]
+	if !ok {
+		return Step{}, fmt.Errorf("workflow %s has no job %q; jobs are: %s", path, jobID, strings.Join(jobIDs(wf), ", "))
+	}
+	if j.Uses != "" {
+		return Step{}, fmt.Errorf("job %q calls reusable workflow %s; use that workflow instead", jobID, j.Uses)
+	}
+	i, err := j.findStep(jobID, stepRef)
+	if err != nil {
+		return Step{}, err
+	}
+	s := j.Steps[i]
+	m, err := j.matrixEntry(matrix)
+	if err != nil {
+		return Step{}, fmt.Errorf("job %q: %w", jobID, err)
+	}
+	out := Step{Job: jobID, Name: s.displayName(i), Inputs: map[string]string{}, Root: repoRoot(path)}
+	for k, n := range s.With {
+		if n.Kind != yaml.ScalarNode {
+			return Step{}, fmt.Errorf("line %d: input %s must be a string, number, or bool", n.Line, k)
+		}
+		if out.Inputs[k], err = evaluate(n.Value, m); err != nil {
+			return Step{}, fmt.Errorf("line %d: input %s: %w", n.Line, k, err)
+		}
+	}
+	return out, nil
+}
+
+func jobIDs(wf workflowFile) []string {
+	ids := make([]string, 0, len(wf.Jobs))
+	for id := range wf.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// findStep returns the index of the step matching ref, or if ref is empty,
+// the only step that uses this action.
+func (j job) findStep(jobID, ref string) (int, error) {
+	if ref != "" {
+		for i, s := range j.Steps {
+			if s.ID == ref || s.Name == ref {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("job %q has no step with id or name %q", jobID, ref)
+	}
+	found := -1
+	for i, s := range j.Steps {
+		if !usesThisAction(s.Uses) {
+			continue
+		}
+		if found != -1 {
+			return 0, fmt.Errorf("job %q has more than one step using actions-go-build; specify one as %s/<step id or name>", jobID, jobID)
+		}
+		found = i
+	}
+	if found == -1 {
+		return 0, fmt.Errorf("job %q has no step using actions-go-build; specify one as %s/<step id or name>", jobID, jobID)
+	}
+	return found, nil
+}
+
+func usesThisAction(uses string) bool {
+	action, _, _ := strings.Cut(uses, "@")
+	return action == "hashicorp/actions-go-build"
+}
+
+func (s step) displayName(i int) string {
+	if s.ID != "" {
+		return s.ID
+	}
+	if s.Name != "" {
+		return s.Name
+	}
+	return fmt.Sprintf("step %d", i+1)
+}
+
+// matrixEntry returns matrix plus any values from the first matching include
+// entry in the job's matrix.
+func (j job) matrixEntry(matrix map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(matrix))
+	for k, v := range matrix {
+		out[k] = v
+	}
+	if j.Strategy.Matrix.Kind != yaml.MappingNode || len(matrix) == 0 {
+		return out, nil
+	}
+	var m struct {
+		Include []map[string]yaml.Node `yaml:"include"`
+	}
+	if err := j.Strategy.Matrix.Decode(&m); err != nil {
+		return nil, fmt.Errorf("reading matrix: %w", err)
+	}
+	for _, entry := range m.Include {
+		if !matches(entry, matrix) {
+			continue
+		}
+		for k, n := range entry {
+			if _, ok := out[k]; !ok && n.Kind == yaml.ScalarNode {
+				out[k] = n.Value
+			}
+		}
+		break
+	}
+	return out, nil
+}
+
+// matches reports whether entry shares at least one key with matrix, and
+// agrees with it on all shared keys.
+func matches(entry map[string]yaml.Node, matrix map[string]string) bool {
+	shared := false
+	for k, v := range matrix {
+		n, ok := entry[k]
+		if !ok {
+			continue
+		}
+		if n.Kind != yaml.ScalarNode || n.Value != v {
+			return false
+		}
+		shared = true
+	}
+	return shared
+}
+
+// repoRoot returns the repository root for the workflow file at path,
+// assuming it's in .github/workflows. Otherwise, it returns the current
+// directory.
+func repoRoot(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "."
+	}
+	workflows := filepath.Dir(abs)
+	dotGitHub := filepath.Dir(workflows)
+	if filepath.Base(workflows) != "workflows" || filepath.Base(dotGitHub) != ".github" {
+		return "."
+	}
+	return filepath.Dir(dotGitHub)
+}
+
+var (
+	expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	matrixPattern     = regexp.MustCompile(`^matrix\.([A-Za-z_][A-Za-z0-9_-]*)$`)
+	numberPattern     = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+)
+
+// evaluate replaces each ${{ expression }} in s with its value.
+func evaluate(s string, matrix map[string]string) (string, error) {
+	var err error
+	out := expressionPattern.ReplaceAllStringFunc(s, func(expr string) string {
+		if err != nil {
+			return ""
+		}
+		var v string
+		v, err = evaluateExpression(strings.TrimSpace(expr[3:len(expr)-2]), matrix)
+		return v
+	})
+	return out, err
+}
+
+func evaluateExpression(expr string, matrix map[string]string) (string, error) {
+	if m := matrixPattern.FindStringSubmatch(expr); m != nil {
+		return matrix[m[1]], nil
+	}
+	if len(expr) >= 2 && strings.HasPrefix(expr, "'") && strings.HasSuffix(expr, "'") {
+		return strings.ReplaceAll(expr[1:len(expr)-1], "''", "'"), nil
+	}
+	switch {
+	case expr == "null":
+		return "", nil
+	case expr == "true", expr == "false", numberPattern.MatchString(expr):
+		return expr, nil
+	}
+	return "", fmt.Errorf("unsupported expression %q; only matrix values and literals are supported", expr)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+const testWorkflow = `
+name: Build
+on: push
+jobs:
+  build:
+    strategy:
+      matrix:
+        include:
+          - { os: darwin, arch: arm64, tags: netcgo }
+          - { os: linux,  arch: amd64, env: CGO_ENABLED=0 }
+    steps:
+      - uses: actions/checkout@v4
+      - name: Build
+        uses: hashicorp/actions-go-build@main
+        with:
+          product_name: example-app
+          go_version: 1.20
+          os: ${{ matrix.os }}
+          arch: ${{matrix.arch}}
+          work_dir: cmd/example
+          debug: ${{ true }}
+          instructions: |-
+            ${{ matrix.env }} go build -tags="${{ matrix.tags }}" -o "$BIN_PATH" # ${{ 'it''s' }}
+  two:
+    steps:
+      - id: first
+        uses: hashicorp/actions-go-build@v1
+        with: { os: linux }
+      - id: second
+        uses: hashicorp/actions-go-build@v1
+        with: { os: "${{ github.ref }}" }
+  reusable:
+    uses: ./.github/workflows/other.yml
+`
+
+func writeWorkflow(t *testing.T) (root, path string) {
+	t.Helper()
+	root = tmp.Dir(t)
+	path = filepath.Join(root, ".github", "workflows", "build.yml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(testWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root, path
+}
+
+func TestRead_ok(t *testing.T) {
+	root, path := writeWorkflow(t)
+
+	cases := []struct {
+		desc, ref string
+		matrix    map[string]string
+		want      Step
+	}{
+		{
+			"include entry fills in matrix", "build", map[string]string{"os": "linux"},
+			Step{Job: "build", Name: "Build", Root: root, Inputs: map[string]string{
+				"product_name": "example-app",
+				"go_version":   "1.20",
+				"os":           "linux",
+				"arch":         "amd64",
+				"work_dir":     "cmd/example",
+				"debug":        "true",
+				"instructions": `CGO_ENABLED=0 go build -tags="" -o "$BIN_PATH" # it's`,
+			}},
+		},
+		{
+			"supplied matrix wins", "build/Build", map[string]string{"os": "darwin", "arch": "amd64"},
+			Step{Job: "build", Name: "Build", Root: root, Inputs: map[string]string{
+				"product_name": "example-app",
+				"go_version":   "1.20",
+				"os":           "darwin",
+				"arch":         "amd64",
+				"work_dir":     "cmd/example",
+				"debug":        "true",
+				"instructions": ` go build -tags="" -o "$BIN_PATH" # it's`,
+			}},
+		},
+		{
+			"step by id", "two/first", nil,
+			Step{Job: "two", Name: "first", Root: root, Inputs: map[string]string{"os": "linux"}},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			got, err := Read(path, c.ref, c.matrix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRead_err(t *testing.T) {
+	_, path := writeWorkflow(t)
+
+	cases := []struct{ ref, want string }{
+		{"nope", `no job "nope"; jobs are: build, reusable, two`},
+		{"build/nope", `job "build" has no step with id or name "nope"`},
+		{"two", `job "two" has more than one step using actions-go-build`},
+		{"two/second", `unsupported expression "github.ref"`},
+		{"reusable", `calls reusable workflow`},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.ref, func(t *testing.T) {
+			_, err := Read(path, c.ref, nil)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("got error %v; want error containing %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestStep_Env(t *testing.T) {
+	s := Step{Root: "/repo", Inputs: map[string]string{"os": "linux", "product_version_meta": "", "goamd64": "v3", "work_dir": "cmd/x"}}
+	want := map[string]string{"OS": "linux", "PRODUCT_VERSION_META": "", "BUILD_GOAMD64": "v3"}
+	if diff := cmp.Diff(s.Env(), want); diff != "" {
+		t.Error(diff)
+	}
+	if got, want := s.WorkDir(), filepath.FromSlash("/repo/cmd/x"); got != want {
+		t.Errorf("got work dir %q; want %q", got, want)
+	}
+}
@@ -4,11 +4,17 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "embed"
 
+	"github.com/hashicorp/actions-go-build/internal/audit"
 	"github.com/hashicorp/actions-go-build/internal/log"
+	"github.com/hashicorp/actions-go-build/internal/telemetry"
 	"github.com/hashicorp/actions-go-build/pkg/commands"
 	"github.com/hashicorp/actions-go-build/pkg/crt"
 	"github.com/hashicorp/actions-go-build/product"
@@ -21,16 +27,43 @@ var versionCore string
 
 func main() {
 
+	// Builds with -audit-inputs run us as the go command's -toolexec wrapper.
+	if audit.IsToolexec(os.Args) {
+		os.Exit(audit.Toolexec(os.Args[1], os.Args[2:]))
+	}
+
 	p, err := product.Product("actions-go-build", versionCore)
 	if err != nil {
 		log.Info("Error: invalid build: invalid product info: %s", err)
 		os.Exit(1)
 	}
 
+	// Cancel running builds on the first SIGINT or SIGTERM. Stop handling
+	// signals once that happens, so a second one exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	commands.SetContext(ctx)
+
+	// Tracing is only for observing builds, so it mustn't stop them.
+	shutdown, err := telemetry.Init(context.Background(), p.Version.Full)
+	if err != nil {
+		log.Info("WARNING: tracing disabled: %s", err)
+	}
+
 	status, err := makeCLI(p, os.Args[1:]).Run()
 	if err != nil {
 		log.Info("%s", err)
 	}
+	stop()
+	// Flush spans, but don't let an unreachable collector hold up exiting.
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdown(flushCtx); err != nil {
+		log.Info("WARNING: exporting traces: %s", err)
+	}
+	cancel()
 	os.Exit(status)
 }
 
@@ -43,11 +76,15 @@ func makeCLI(thisTool crt.Product, args []string) *cli.CLI {
 	c.Args = args
 
 	c.Commands = map[string]cli.CommandFactory{
-		"build":   makeCommand(commands.Build),
-		"config":  makeCommand(commands.Config),
-		"inspect": makeCommand(commands.Inspect),
-		"verify":  makeCommand(commands.Verify),
-		"version": makeCommand(versionCommand),
+		"build":           makeCommand(commands.Build),
+		"config":          makeCommand(commands.Config),
+		"explain":         makeCommand(commands.Explain),
+		"inspect":         makeCommand(commands.Inspect),
+		"manifest":        makeCommand(commands.Manifest),
+		"verify":          makeCommand(commands.Verify),
+		"verify-artifact": makeCommand(commands.VerifyArtifact),
+		"verify-release":  makeCommand(commands.VerifyRelease),
+		"version":         makeCommand(versionCommand),
 	}
 
 	return c
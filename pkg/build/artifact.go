@@ -0,0 +1,336 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"archive/zip"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/internal/scan"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+// CheckStatus is the outcome of a single artifact check.
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "passed"
+	CheckFailed  CheckStatus = "failed"
+	CheckSkipped CheckStatus = "skipped"
+	// CheckWeak means the value was found somewhere in the executable, but
+	// not necessarily where it was wanted.
+	CheckWeak CheckStatus = "weak"
+)
+
+// ArtifactCheck compares one property of a downloaded artifact with the
+// value recorded in, or derived from, its build result.
+type ArtifactCheck struct {
+	Name, Want, Got string
+	Status          CheckStatus
+}
+
+// ArtifactVerification is the outcome of checking a downloaded zip against
+// the build result that describes it, without rebuilding it.
+type ArtifactVerification struct {
+	// Zip is the path of the zip that was checked.
+	Zip          string
+	Product      string
+	Version      string
+	Platform     string
+	Checks       []ArtifactCheck
+	ErrorMessage string `json:",omitempty"`
+	Verified     bool
+}
+
+func (av *ArtifactVerification) Error() error {
+	if av.ErrorMessage == "" {
+		return nil
+	}
+	return errors.New(av.ErrorMessage)
+}
+
+func (av *ArtifactVerification) IsFromCache() bool { return false }
+
+func (av *ArtifactVerification) check(name, want, got string) {
+	status := CheckPassed
+	if want != got {
+		status = CheckFailed
+	}
+	av.Checks = append(av.Checks, ArtifactCheck{Name: name, Want: want, Got: got, Status: status})
+}
+
+func (av *ArtifactVerification) skip(name, want, why string) {
+	av.Checks = append(av.Checks, ArtifactCheck{Name: name, Want: want, Got: why, Status: CheckSkipped})
+}
+
+// VerifyArtifact checks that the zip at zipPath is the one described by r:
+// that its digest and size match, that it contains the executable with the
+// recorded digest and size, and that the product, version, and platform in
+// the zip's name and embedded in the executable are as configured.
+func VerifyArtifact(zipPath string, r Result) (*ArtifactVerification, error) {
+	c := r.Config
+	av := &ArtifactVerification{
+		Zip:      zipPath,
+		Product:  c.Product.Name,
+		Version:  c.Product.Version.Full,
+		Platform: c.Parameters.OS + "/" + c.Parameters.Arch,
+	}
+	zf, err := getFileDetails(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	av.check("zip size", strconv.FormatInt(r.Zip.Size, 10), strconv.FormatInt(zf.Size, 10))
+	av.check("zip SHA256", r.Zip.SHA256Sum, zf.SHA256Sum)
+	av.zipNameChecks(filepath.Base(zipPath), r)
+	// There's no point looking inside a zip that isn't the one described.
+	if zf.SHA256Sum != r.Zip.SHA256Sum {
+		av.skip("executable", r.Executable.Name, "zip SHA256 doesn't match")
+	} else if err := av.executableChecks(zipPath, r); err != nil {
+		return nil, err
+	}
+	var failed []string
+	for _, ch := range av.Checks {
+		if ch.Status == CheckFailed {
+			failed = append(failed, fmt.Sprintf("%s is %q; want %q", ch.Name, ch.Got, ch.Want))
+		}
+	}
+	av.ErrorMessage = strings.Join(failed, "; ")
+	av.Verified = len(failed) == 0
+	return av, nil
+}
+
+// zipNameChecks checks the product name, version, platform, and variant in
+// the name of the zip. If the build used a custom zip name, it must match
+// exactly.
+func (av *ArtifactVerification) zipNameChecks(name string, r Result) {
+	p, bp := r.Config.Product, r.Config.Parameters
+	if r.Zip.Name != bp.defaultZipName(p) {
+		av.check("zip name", r.Zip.Name, name)
+		return
+	}
+	// Product names may contain underscores, but the other fields can't.
+	n := strings.Count(p.Name, "_") + 1
+	fields := strings.Split(strings.TrimSuffix(name, ".zip"), "_")
+	field := func(i, j int) string {
+		if len(fields) < j {
+			return ""
+		}
+		return strings.Join(fields[i:j], "_")
+	}
+	av.check("zip name product", p.Name, field(0, n))
+	av.check("zip name version", p.Version.Full, field(n, n+1))
+	av.check("zip name platform", bp.OS+"_"+bp.Arch, field(n+1, n+3))
+	var variant string
+	if len(fields) > n+3 {
+		variant = "_" + strings.Join(fields[n+3:], "_")
+	}
+	av.check("zip name variant", bp.variant(), variant)
+}
+
+// executableChecks extracts the executable from the zip to a temporary
+// file, and checks it.
+func (av *ArtifactVerification) executableChecks(zipPath string, r Result) error {
+	if name := r.Executable.Name; name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid executable name %q in build result", name)
+	}
+	dir, err := os.MkdirTemp("", "actions-go-build.artifact")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	exe := filepath.Join(dir, r.Executable.Name)
+	// Extracting one byte more than wanted is enough to fail the size check.
+	found, err := extractFile(zipPath, r.Executable.Name, exe, r.Executable.Size+1)
+	if err != nil {
+		return err
+	}
+	if !found {
+		av.check("executable", r.Executable.Name, "not in zip")
+		return nil
+	}
+	ef, err := getFileDetails(exe)
+	if err != nil {
+		return err
+	}
+	av.check("executable size", strconv.FormatInt(r.Executable.Size, 10), strconv.FormatInt(ef.Size, 10))
+	av.check("executable SHA256", r.Executable.SHA256Sum, ef.SHA256Sum)
+	bp := r.Config.Parameters
+	settings := map[string]string{}
+	info, err := buildinfo.ReadFile(exe)
+	if err != nil {
+		av.check("executable Go build info", "present", err.Error())
+	} else {
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		av.check("executable Go version", bp.GoVersion, parseGoVersion(info.GoVersion))
+		av.check("executable platform", bp.OS+"/"+bp.Arch, settings["GOOS"]+"/"+settings["GOARCH"])
+		if rev, ok := settings["vcs.revision"]; ok {
+			av.check("executable VCS revision", r.Config.Product.Revision, rev)
+		}
+	}
+	if ldflags, ok := settings["-ldflags"]; ok {
+		av.productInfoChecks(ldflags, r)
+		return nil
+	}
+	return av.scannedProductInfoChecks(exe, r)
+}
+
+// productInfoWants are the product info checks, and the variables in the
+// product package that they check.
+func productInfoWants(p crt.Product) []struct{ name, variable, value string } {
+	return []struct{ name, variable, value string }{
+		{"embedded product name", "Name", p.Name},
+		{"embedded version", "VersionFull", p.Version.Full},
+		{"embedded revision", "Revision", p.Revision},
+	}
+}
+
+// productInfoChecks checks the product info injected into the product
+// package, using the -ldflags recorded in the executable's build info.
+// Values that weren't injected are skipped. If the product's module isn't
+// known, any package called product will do.
+func (av *ArtifactVerification) productInfoChecks(ldflags string, r Result) {
+	p := r.Config.Product
+	injected := ldflagsVars(ldflags)
+	lookup := func(variable string) (string, bool) {
+		if p.Module != "" {
+			v, ok := injected[p.Module+"/product."+variable]
+			return v, ok
+		}
+		for name, v := range injected {
+			if path.Base(name) == "product."+variable {
+				return v, true
+			}
+		}
+		return "", false
+	}
+	for _, w := range productInfoWants(p) {
+		if got, ok := lookup(w.variable); ok {
+			av.check(w.name, w.value, got)
+		} else {
+			av.skip(w.name, w.value, "not injected by -ldflags")
+		}
+	}
+}
+
+// ldflagsVars returns the values set by -X flags in ldflags, keyed by the
+// package path and variable name, e.g. "example.com/foo/product.Name". Like the go command, it splits ldflags on spaces, except
+// within single or double quotes.
+func ldflagsVars(ldflags string) map[string]string {
+	var args []string
+	for ldflags = strings.TrimSpace(ldflags); ldflags != ""; ldflags = strings.TrimSpace(ldflags) {
+		if q := ldflags[0]; q == '\'' || q == '"' {
+			if end := strings.IndexByte(ldflags[1:], q); end >= 0 {
+				args = append(args, ldflags[1:end+1])
+				ldflags = ldflags[end+2:]
+				continue
+			}
+		}
+		end := strings.IndexAny(ldflags, " \t\n\r")
+		if end < 0 {
+			end = len(ldflags)
+		}
+		args = append(args, ldflags[:end])
+		ldflags = ldflags[end:]
+	}
+	vars := map[string]string{}
+	for i, a := range args {
+		var def string
+		switch {
+		case (a == "-X" || a == "--X") && i+1 < len(args):
+			def = args[i+1]
+		case strings.HasPrefix(a, "-X="):
+			def = strings.TrimPrefix(a, "-X=")
+		case strings.HasPrefix(a, "--X="):
+			def = strings.TrimPrefix(a, "--X=")
+		default:
+			continue
+		}
+		if name, value, ok := strings.Cut(def, "="); ok {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// scannedProductInfoChecks checks the product info for executables with no
+// -ldflags in their build info, by looking for the values anywhere in the
+// executable. Values found this way are only weakly checked, because they
+// may be there for other reasons. Values that the build instructions (like
+// the default ones) don't inject into the product package are skipped. The
+// linker ignores values for packages that aren't linked, so if the revision
+// was injected but isn't there, the product package isn't used and all the
+// checks are skipped.
+func (av *ArtifactVerification) scannedProductInfoChecks(exe string, r Result) error {
+	p, instructions := r.Config.Product, r.Config.Parameters.Instructions
+	wants := productInfoWants(p)
+	var needles []string
+	for _, w := range wants {
+		needles = append(needles, w.value)
+	}
+	hits, err := scan.File(exe, needles...)
+	if err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, h := range hits {
+		found[h.Needle] = true
+	}
+	injected := func(variable string) bool {
+		return strings.Contains(instructions, "/product."+variable+"=")
+	}
+	linked := !injected("Revision") || found[p.Revision]
+	for _, w := range wants {
+		switch {
+		case !injected(w.variable):
+			av.skip(w.name, w.value, "not injected by the build instructions")
+		case !linked:
+			av.skip(w.name, w.value, "product package not in executable")
+		case found[w.value]:
+			av.Checks = append(av.Checks, ArtifactCheck{Name: w.name, Want: w.value, Got: w.value, Status: CheckWeak})
+		default:
+			av.check(w.name, w.value, "not found")
+		}
+	}
+	return nil
+}
+
+// extractFile writes at most limit bytes of the root-level entry called name
+// in the zip at zipPath to dst, and reports whether there was such an entry.
+func extractFile(zipPath, name, dst string, limit int64) (bool, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return false, err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if path.Clean(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return false, err
+		}
+		defer rc.Close()
+		out, err := os.Create(dst)
+		if err != nil {
+			return false, err
+		}
+		if _, err := io.Copy(out, io.LimitReader(rc, limit)); err != nil {
+			_ = out.Close()
+			return false, err
+		}
+		return true, out.Close()
+	}
+	return false, nil
+}
@@ -0,0 +1,339 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"archive/zip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeArtifact writes a zip called zipName containing an executable called
+// lockbox with contents exe, and returns the zip's path and a result
+// describing it.
+func writeArtifact(t *testing.T, zipName, exe string) (string, Result) {
+	t.Helper()
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "lockbox")
+	if err := os.WriteFile(exePath, []byte(exe), 0755); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(dir, zipName)
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("lockbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(exe)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := Result{Config: standardConfig(dir)}
+	r.Config.Parameters.Instructions = "go build -ldflags \"" +
+		"-X 'dadgarcorp/lockbox/product.Name=lockbox' " +
+		"-X 'dadgarcorp/lockbox/product.VersionFull=1.2.3' " +
+		"-X 'dadgarcorp/lockbox/product.Revision=cabba9e'\""
+	if r.Zip, err = getFileDetails(zipPath); err != nil {
+		t.Fatal(err)
+	}
+	if r.Executable, err = getFileDetails(exePath); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath, r
+}
+
+type checkStatuses map[string]CheckStatus
+
+func statuses(av *ArtifactVerification) checkStatuses {
+	s := checkStatuses{}
+	for _, c := range av.Checks {
+		s[c.Name] = c.Status
+	}
+	return s
+}
+
+func TestVerifyArtifact(t *testing.T) {
+	zipName := "lockbox_1.2.3_linux_amd64.zip"
+	passed := checkStatuses{
+		"zip size":          CheckPassed,
+		"zip SHA256":        CheckPassed,
+		"zip name product":  CheckPassed,
+		"zip name version":  CheckPassed,
+		"zip name platform": CheckPassed,
+		"zip name variant":  CheckPassed,
+		"executable size":   CheckPassed,
+		"executable SHA256": CheckPassed,
+		// The test executable isn't a Go binary, so it has no build info,
+		// and the product info can only be found by scanning it.
+		"executable Go build info": CheckFailed,
+		"embedded product name":    CheckWeak,
+		"embedded version":         CheckWeak,
+		"embedded revision":        CheckWeak,
+	}
+	with := func(changes checkStatuses) checkStatuses {
+		s := checkStatuses{}
+		for k, v := range passed {
+			s[k] = v
+		}
+		for k, v := range changes {
+			if v == "" {
+				delete(s, k)
+			} else {
+				s[k] = v
+			}
+		}
+		return s
+	}
+
+	cases := []struct {
+		desc, zipName, exe string
+		modify             func(*Result)
+		want               checkStatuses
+	}{
+		{
+			"matching",
+			zipName, "name=lockbox version=1.2.3 rev=cabba9e",
+			func(*Result) {},
+			passed,
+		},
+		{
+			"wrong zip",
+			zipName, "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) { r.Zip.SHA256Sum, r.Zip.Size = "abc", 1 },
+			checkStatuses{
+				"zip size":          CheckFailed,
+				"zip SHA256":        CheckFailed,
+				"zip name product":  CheckPassed,
+				"zip name version":  CheckPassed,
+				"zip name platform": CheckPassed,
+				"zip name variant":  CheckPassed,
+				"executable":        CheckSkipped,
+			},
+		},
+		{
+			"wrong zip name",
+			"lockbox_1.2.4_darwin_arm64.zip", "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) { r.Zip.Name = zipName },
+			with(checkStatuses{"zip name version": CheckFailed, "zip name platform": CheckFailed}),
+		},
+		{
+			"custom zip name",
+			"custom.zip", "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) {},
+			with(checkStatuses{"zip name product": "", "zip name version": "", "zip name platform": "", "zip name variant": "", "zip name": CheckPassed}),
+		},
+		{
+			"microarchitecture variant",
+			"lockbox_1.2.3_linux_amd64_v3_nocgo.zip", "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) { r.Config.Parameters.GOAMD64, r.Config.Parameters.CGOEnabled = "v3", "0" },
+			passed,
+		},
+		{
+			"arm variant",
+			"lockbox_1.2.3_linux_arm_v7.zip", "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) { r.Config.Parameters.Arch, r.Config.Parameters.GOARM = "arm", "7" },
+			passed,
+		},
+		{
+			"wrong variant",
+			"lockbox_1.2.3_linux_amd64_v2.zip", "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) {
+				r.Config.Parameters.GOAMD64 = "v3"
+				r.Zip.Name = "lockbox_1.2.3_linux_amd64_v3.zip"
+			},
+			with(checkStatuses{"zip name variant": CheckFailed}),
+		},
+		{
+			"wrong embedded version",
+			zipName, "name=lockbox version=1.2.4 rev=cabba9e",
+			func(*Result) {},
+			with(checkStatuses{"embedded version": CheckFailed}),
+		},
+		{
+			"product package not linked",
+			zipName, "not much here",
+			func(*Result) {},
+			with(checkStatuses{"embedded product name": CheckSkipped, "embedded version": CheckSkipped, "embedded revision": CheckSkipped}),
+		},
+		{
+			"not injected",
+			zipName, "not much here",
+			func(r *Result) { r.Config.Parameters.Instructions = "go build -o $BIN_PATH" },
+			with(checkStatuses{"embedded product name": CheckSkipped, "embedded version": CheckSkipped, "embedded revision": CheckSkipped}),
+		},
+		{
+			"executable larger than recorded",
+			zipName, "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) { r.Executable.Size -= 10 },
+			// Only one byte more than the recorded size is extracted, which cuts
+			// off the revision.
+			with(checkStatuses{
+				"executable size":       CheckFailed,
+				"executable SHA256":     CheckFailed,
+				"embedded product name": CheckSkipped,
+				"embedded version":      CheckSkipped,
+				"embedded revision":     CheckSkipped,
+			}),
+		},
+		{
+			"missing executable",
+			zipName, "name=lockbox version=1.2.3 rev=cabba9e",
+			func(r *Result) { r.Executable.Name = "vault" },
+			checkStatuses{
+				"zip size":          CheckPassed,
+				"zip SHA256":        CheckPassed,
+				"zip name product":  CheckPassed,
+				"zip name version":  CheckPassed,
+				"zip name platform": CheckPassed,
+				"zip name variant":  CheckPassed,
+				"executable":        CheckFailed,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			zipPath, r := writeArtifact(t, c.zipName, c.exe)
+			c.modify(&r)
+			got, err := VerifyArtifact(zipPath, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, statuses(got)); diff != "" {
+				t.Error(diff)
+			}
+			// None of the executables are Go binaries, so none are verified.
+			if got.Verified || got.ErrorMessage == "" {
+				t.Errorf("got verified %t with error %q; want failure", got.Verified, got.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestVerifyArtifact_goExecutable(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipPath, r := writeArtifact(t, "lockbox_1.2.3_linux_amd64.zip", string(data))
+	r.Config.Parameters.Instructions = "go build -o $BIN_PATH"
+	got, err := VerifyArtifact(zipPath, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := statuses(got)
+	if s["executable Go version"] == "" || s["executable platform"] == "" {
+		t.Errorf("got checks %v; want Go version and platform checks", s)
+	}
+	if _, ok := s["executable Go build info"]; ok {
+		t.Errorf("got a failed Go build info check for a Go executable")
+	}
+}
+
+func TestVerifyArtifact_invalidExecutableName(t *testing.T) {
+	zipPath, r := writeArtifact(t, "lockbox_1.2.3_linux_amd64.zip", "")
+	r.Executable.Name = "../lockbox"
+	if _, err := VerifyArtifact(zipPath, r); err == nil {
+		t.Errorf("got nil error for executable name %q", r.Executable.Name)
+	}
+}
+
+// goExecutable builds an empty Go program with ldflags, and returns the
+// executable's contents.
+func goExecutable(t *testing.T, ldflags string) string {
+	t.Helper()
+	dir := t.TempDir()
+	must(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/empty\n"), 0644))
+	must(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	cmd := exec.Command("go", "build", "-buildvcs=false", "-ldflags", ldflags, "-o", "empty")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	exe, err := os.ReadFile(filepath.Join(dir, "empty"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(exe)
+}
+
+func TestVerifyArtifact_ldflags(t *testing.T) {
+	const module = "dadgarcorp/lockbox"
+	embedded := func(name, version, revision CheckStatus) checkStatuses {
+		return checkStatuses{"embedded product name": name, "embedded version": version, "embedded revision": revision}
+	}
+	cases := []struct {
+		desc, module, ldflags string
+		want                  checkStatuses
+	}{
+		{
+			"matching",
+			module,
+			"-X 'dadgarcorp/lockbox/product.Name=lockbox' -X \"dadgarcorp/lockbox/product.VersionFull=1.2.3\" " +
+				"-X=dadgarcorp/lockbox/product.Revision=cabba9e",
+			embedded(CheckPassed, CheckPassed, CheckPassed),
+		},
+		{
+			"wrong version",
+			module,
+			"-X 'dadgarcorp/lockbox/product.Name=lockbox' -X 'dadgarcorp/lockbox/product.VersionFull=1.2.4' " +
+				"-X 'dadgarcorp/lockbox/product.Revision=cabba9e'",
+			embedded(CheckPassed, CheckFailed, CheckPassed),
+		},
+		{
+			// Values mentioned anywhere else don't count.
+			"not injected",
+			module,
+			"-X 'dadgarcorp/lockbox/product.Unused=lockbox 1.2.3 cabba9e' -s",
+			embedded(CheckSkipped, CheckSkipped, CheckSkipped),
+		},
+		{
+			"another module's product package",
+			module,
+			"-X 'example.com/dep/product.Name=lockbox'",
+			embedded(CheckSkipped, CheckSkipped, CheckSkipped),
+		},
+		{
+			"unknown module",
+			"",
+			"-X 'example.com/dep/product.Name=lockbox'",
+			embedded(CheckPassed, CheckSkipped, CheckSkipped),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			zipPath, r := writeArtifact(t, "lockbox_1.2.3_linux_amd64.zip", goExecutable(t, c.ldflags))
+			r.Config.Product.Module = c.module
+			got, err := VerifyArtifact(zipPath, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotEmbedded := checkStatuses{}
+			for name, status := range statuses(got) {
+				if _, ok := c.want[name]; ok {
+					gotEmbedded[name] = status
+				}
+			}
+			if diff := cmp.Diff(c.want, gotEmbedded); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
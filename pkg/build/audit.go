@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/internal/audit"
+)
+
+// Reasons that an input is undeclared.
+const (
+	InputUntracked = "untracked"
+	InputIgnored   = "gitignored"
+)
+
+// UndeclaredInput is a source file the build read from the work dir that
+// isn't in the product revision, so it's not covered by the source hash
+// and won't be there when the build is reproduced remotely.
+type UndeclaredInput struct {
+	// Path is relative to the work dir.
+	Path string
+	// Reason is InputUntracked or InputIgnored.
+	Reason string
+}
+
+func (ui UndeclaredInput) String() string { return fmt.Sprintf("%s (%s)", ui.Path, ui.Reason) }
+
+// ToolexecPathFunc returns the program run as the go command's -toolexec
+// wrapper when auditing inputs. It must be this program, and tests can
+// override it.
+var ToolexecPathFunc = os.Executable
+
+// inputAuditor is implemented by builds that audit the source files read
+// by their instructions.
+type inputAuditor interface {
+	undeclaredInputs() []UndeclaredInput
+}
+
+func (b *core) undeclaredInputs() []UndeclaredInput { return b.undeclared }
+
+// runAudited runs c, having the go command record the source files it reads,
+// then records any that are undeclared.
+func (b *core) runAudited(c command) error {
+	dir, err := os.MkdirTemp("", "actions-go-build.audit")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	logPath := filepath.Join(dir, "inputs.log")
+	env, err := auditEnv(logPath, filepath.Join(dir, "gocache"))
+	if err != nil {
+		return err
+	}
+	// An empty build cache makes sure every package is compiled, so we
+	// see all of their inputs.
+	b.Log("Auditing inputs using an empty Go build cache.")
+	c.Env = append(c.Env, env...)
+	if err := c.Run(); err != nil {
+		return err
+	}
+	inputs, err := readInputs(logPath)
+	if err != nil {
+		return err
+	}
+	b.undeclared, err = findUndeclaredInputs(b.context, b.config.Paths.WorkDir, inputs)
+	return err
+}
+
+// auditEnv returns the env vars that make the go command record the files
+// it reads to logPath, using the build cache in cacheDir.
+func auditEnv(logPath, cacheDir string) ([]string, error) {
+	exe, err := ToolexecPathFunc()
+	if err != nil {
+		return nil, err
+	}
+	// GOFLAGS is split on spaces, so there's no way to quote them.
+	if strings.ContainsAny(exe, " \t") {
+		return nil, fmt.Errorf("cannot audit inputs: path %q contains spaces", exe)
+	}
+	goflags := strings.TrimSpace(os.Getenv("GOFLAGS") + " -toolexec=" + exe)
+	return []string{"GOFLAGS=" + goflags, audit.LogEnv + "=" + logPath, "GOCACHE=" + cacheDir}, nil
+}
+
+// readInputs returns the unique paths in the log at path, which doesn't
+// exist if the go command didn't compile anything.
+func readInputs(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var inputs []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		if in := s.Text(); !seen[in] {
+			seen[in] = true
+			inputs = append(inputs, in)
+		}
+	}
+	return inputs, s.Err()
+}
+
+// findUndeclaredInputs returns the inputs inside workDir that aren't in the
+// HEAD commit of the repository there.
+func findUndeclaredInputs(ctx context.Context, workDir string, inputs []string) ([]UndeclaredInput, error) {
+	rels := relativeInputs(workDir, inputs)
+	if len(rels) == 0 {
+		return nil, nil
+	}
+	out, err := gitOutput(ctx, workDir, nil, "ls-tree", "-r", "-z", "--name-only", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	tracked := map[string]bool{}
+	for _, p := range splitNUL(out) {
+		tracked[p] = true
+	}
+	var untracked []string
+	for _, rel := range rels {
+		if !tracked[rel] {
+			untracked = append(untracked, rel)
+		}
+	}
+	if len(untracked) == 0 {
+		return nil, nil
+	}
+	stdin := strings.Join(untracked, "\x00") + "\x00"
+	out, err = gitOutput(ctx, workDir, strings.NewReader(stdin), "check-ignore", "--stdin", "-z")
+	// Exit status 1 means none of the paths are ignored.
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, err
+	}
+	ignored := map[string]bool{}
+	for _, p := range splitNUL(out) {
+		ignored[p] = true
+	}
+	undeclared := make([]UndeclaredInput, len(untracked))
+	for i, p := range untracked {
+		undeclared[i] = UndeclaredInput{Path: p, Reason: InputUntracked}
+		if ignored[p] {
+			undeclared[i].Reason = InputIgnored
+		}
+	}
+	return undeclared, nil
+}
+
+// relativeInputs returns the sorted paths of inputs inside workDir,
+// relative to it and slash-separated like git paths.
+func relativeInputs(workDir string, inputs []string) []string {
+	roots := []string{workDir}
+	if real, err := filepath.EvalSymlinks(workDir); err == nil && real != workDir {
+		roots = append(roots, real)
+	}
+	var rels []string
+	for _, in := range inputs {
+		for _, root := range roots {
+			rel, err := filepath.Rel(root, in)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				rels = append(rels, filepath.ToSlash(rel))
+				break
+			}
+		}
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+func splitNUL(b []byte) []string {
+	var out []string
+	for _, p := range strings.Split(string(b), "\x00") {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// RecordUndeclaredInputs records any undeclared inputs found by auditing
+// the build's inputs.
+func (br *Runner) RecordUndeclaredInputs() error {
+	a, ok := br.build.(inputAuditor)
+	if !ok {
+		return nil
+	}
+	br.result.UndeclaredInputs = a.undeclaredInputs()
+	for _, ui := range br.result.UndeclaredInputs {
+		br.Loud("WARNING: undeclared input %s", ui)
+	}
+	return nil
+}
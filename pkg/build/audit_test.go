@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+func TestFindUndeclaredInputs(t *testing.T) {
+	dir := tmp.Dir(t)
+	testBuild, err := New("test-build", standardConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testBuild.(*core)
+	b.writeTestFile(t, ".gitignore", "generated.go\n")
+	b.createTestProductRepo(t)
+	b.writeTestFile(t, "generated.go", "package main\n")
+	b.writeTestFile(t, "extra.go", "package main\n")
+
+	inputs := []string{
+		filepath.Join(dir, "main.go"),
+		filepath.Join(dir, "generated.go"),
+		filepath.Join(dir, "extra.go"),
+		"/usr/local/go/src/fmt/print.go",
+	}
+	got, err := findUndeclaredInputs(context.Background(), dir, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UndeclaredInput{
+		{Path: "extra.go", Reason: InputUntracked},
+		{Path: "generated.go", Reason: InputIgnored},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -36,6 +37,8 @@ func New(name string, cfg Config, options ...Option) (Build, error) {
 type core struct {
 	Settings
 	config Config
+	// undeclared is set by auditing the build's inputs.
+	undeclared []UndeclaredInput
 }
 
 func errDirtyWorktree(dirtyFiles []string) error {
@@ -81,7 +84,11 @@ func (b *core) ChangeRoot(dir string) error {
 }
 
 func (b *core) ChangeToVerificationRoot() error {
-	return b.ChangeRoot(b.config.VerificationRoot())
+	root := b.config.VerificationRoot()
+	if v := b.activeVariance(); v != nil {
+		root = v.root(root)
+	}
+	return b.ChangeRoot(filepath.Join(root, b.sourceSubdir))
 }
 
 func (b *core) ChangeToPrimaryRoot() error {
@@ -167,15 +174,28 @@ func (b *core) executableWasWritten() (bool, error) {
 	return fs.FileExists(b.config.Paths.BinPath)
 }
 
-func (b *core) newCommand(name string, args ...string) *exec.Cmd {
+// command is an exec.Cmd run in its own process group.
+type command struct {
+	*exec.Cmd
+	reap func()
+}
+
+// Run runs the command, then kills anything left in its process group if it
+// was cancelled.
+func (c command) Run() error {
+	defer c.reap()
+	return c.Cmd.Run()
+}
+
+func (b *core) newCommand(name string, args ...string) command {
 	cmd := exec.CommandContext(b.Settings.context, name, args...)
 	cmd.Dir = b.config.Paths.WorkDir
-	cmd.Stdout = b.Settings.stdout
-	cmd.Stderr = b.Settings.stderr
-	return cmd
+	cmd.Stdout, cmd.Stderr = b.Settings.outputs()
+	return command{cmd, setProcessGroup(cmd, b.Settings.gracePeriod)}
 }
 
 func (b *core) runCommand(name string, args ...string) error {
+	defer b.flushOutputs()
 	return b.newCommand(name, args...).Run()
 }
 
@@ -189,8 +209,31 @@ func (b *core) runInstructions() error {
 	c.Env = b.Env()
 	b.Log("Build environment determined by config:\n%s", strings.Join(c.Env, "\n"))
 	c.Env = append(os.Environ(), c.Env...)
+	if v := b.activeVariance(); v != nil {
+		varianceEnv, err := v.env()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := v.removeHome(); err != nil {
+				b.Log("Failed to remove variance home directory: %s", err)
+			}
+		}()
+		b.Log("Variance applied: %s", v)
+		c.Env = append(c.Env, varianceEnv...)
+	}
+	if b.concurrent {
+		// Later values win, so this overrides any GOCACHE set above.
+		gocache := b.Dirs().GoCacheDir()
+		b.Log("Using separate Go build cache: %s", gocache)
+		c.Env = append(c.Env, "GOCACHE="+gocache)
+	}
 	b.Debug("Full build environment:\n%s", strings.Join(c.Env, "\n"))
 
+	defer b.flushOutputs()
+	if b.auditInputs {
+		return b.runAudited(c)
+	}
 	return c.Run()
 }
 
@@ -198,5 +241,9 @@ func (b *core) runInstructions() error {
 // and returns its path, or an error if writing fails.
 func (b *core) writeInstructions() (path string, err error) {
 	b.Log("Build instructions:\n%s", b.config.Parameters.Instructions)
-	return fs.WriteTempFile("actions-go-build.instructions", b.config.Parameters.Instructions)
+	instructions := b.config.Parameters.Instructions
+	if v := b.activeVariance(); v != nil {
+		instructions = v.instructions(instructions)
+	}
+	return fs.WriteTempFile("actions-go-build.instructions", instructions)
 }
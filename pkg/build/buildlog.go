@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+// LogFileName is the name of the build log written to the meta dir.
+const LogFileName = "build.log"
+
+// LogPath is the path the build log is written to.
+func (bp Paths) LogPath() string {
+	return filepath.Join(bp.MetaDir, LogFileName)
+}
+
+// buildLog collects the output of a build's commands, with each line
+// timestamped, interleaved with markers for the start and end of each step.
+type buildLog struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	now     func() time.Time
+	midLine bool
+}
+
+func newBuildLog(now func() time.Time) *buildLog {
+	return &buildLog{now: now}
+}
+
+// Write timestamps each line in p as it's written to the log.
+func (l *buildLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for rest := p; len(rest) != 0; {
+		if !l.midLine {
+			l.timestamp()
+		}
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i != -1 {
+			line = rest[:i+1]
+		}
+		l.buf.Write(line)
+		l.midLine = line[len(line)-1] != '\n'
+		rest = rest[len(line):]
+	}
+	return len(p), nil
+}
+
+// mark writes a step marker line to the log, starting a new line first if
+// the last output didn't end with one.
+func (l *buildLog) mark(format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.midLine {
+		l.buf.WriteByte('\n')
+		l.midLine = false
+	}
+	l.timestamp()
+	fmt.Fprintf(&l.buf, "==> "+format+"\n", a...)
+}
+
+func (l *buildLog) timestamp() {
+	l.buf.WriteString(l.now().UTC().Format("2006-01-02T15:04:05.000Z07:00 "))
+}
+
+// save writes the log to path, and returns its details.
+func (l *buildLog) save(path string) (crt.File, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return crt.File{}, err
+	}
+	if err := os.WriteFile(path, l.buf.Bytes(), 0644); err != nil {
+		return crt.File{}, err
+	}
+	return getFileDetails(path)
+}
+
+// buildLogSetter is implemented by builds whose commands' output can be
+// teed to a build log.
+type buildLogSetter interface {
+	setBuildLog(io.Writer)
+}
+
+func (b *core) setBuildLog(w io.Writer) { b.Settings.buildLog = w }
+
+// outputs returns the stdout and stderr for commands, teeing them to the
+// build log if there is one.
+func (s *Settings) outputs() (stdout, stderr io.Writer) {
+	if s.buildLog == nil {
+		return s.stdout, s.stderr
+	}
+	return io.MultiWriter(s.stdout, s.buildLog), io.MultiWriter(s.stderr, s.buildLog)
+}
@@ -23,6 +23,9 @@ type Config struct {
 	// Reproducible tells us whether the build is expected to be reproducible.
 	// This is used by downstream processes.
 	Reproducible bool
+	// Sources records where each input value was read from, keyed by
+	// environment variable name. See config.Sources.
+	Sources map[string]string `json:",omitempty"`
 }
 
 // NewConfig expects product, params, and paths to be fully initialized.
@@ -17,11 +17,19 @@ type EnvVar struct {
 // Env materialises the values for each defined env var as a slice
 // compatible with exec.CMD.Env.
 func (b *core) Env() []string {
-	bed := BuildEnvDefinitions()
+	bed := append(InvariantBuildEnvDefinitions(), BuildSpecificBuildEnvDefinitions()...)
+	if b.config.Parameters.CGOEnabled == "1" {
+		bed = append(bed, CToolchainBuildEnvDefinitions()...)
+	}
 	env := make([]string, len(bed))
 	for i, e := range bed {
 		env[i] = fmt.Sprintf("%s=%s", e.Name, e.valueFunc(b.config))
 	}
+	for _, e := range GoVariantBuildEnvDefinitions() {
+		if v := e.valueFunc(b.config); v != "" {
+			env = append(env, fmt.Sprintf("%s=%s", e.Name, v))
+		}
+	}
 	return env
 }
 
@@ -70,6 +78,21 @@ func InvariantBuildEnvDefinitions() []EnvVar {
 			"Same as `ARCH`.",
 			func(c Config) string { return c.Parameters.Arch },
 		},
+		{
+			"BUILD_TAGS",
+			"Same as the `build_tags` input, normalised to a sorted, comma-separated list.",
+			func(c Config) string { return c.Parameters.Tags },
+		},
+		{
+			"MAIN_PACKAGE",
+			"Same as the `main_package` input.",
+			func(c Config) string { return c.Parameters.MainPackage },
+		},
+		{
+			"EXTRA_LDFLAGS",
+			"Same as the `extra_ldflags` input.",
+			func(c Config) string { return c.Parameters.LDFlags },
+		},
 		{
 			"WORKTREE_DIRTY",
 			"Whether the workrtree is dirty (`true` or `false`).",
@@ -83,6 +106,28 @@ func InvariantBuildEnvDefinitions() []EnvVar {
 	}
 }
 
+// GoVariantBuildEnvDefinitions are invariant like the ones above, but are only
+// set when they're configured, so that otherwise the host's values apply.
+func GoVariantBuildEnvDefinitions() []EnvVar {
+	return []EnvVar{
+		{
+			"CGO_ENABLED",
+			"`1` or `0` if the `cgo_enabled` input is set, otherwise the host's value.",
+			func(c Config) string { return c.Parameters.CGOEnabled },
+		},
+		{
+			"GOARM",
+			"Same as the `goarm` input when `ARCH` is `arm`, otherwise the host's value.",
+			func(c Config) string { return c.Parameters.GOARM },
+		},
+		{
+			"GOAMD64",
+			"Same as the `goamd64` input when `ARCH` is `amd64`, otherwise the host's value.",
+			func(c Config) string { return c.Parameters.GOAMD64 },
+		},
+	}
+}
+
 // BuildSpecificBuildEnvDefinitions are environment variables that are expected to be
 // different between different runs without affecting the built artifacts.
 func BuildSpecificBuildEnvDefinitions() []EnvVar {
@@ -100,9 +145,39 @@ func BuildSpecificBuildEnvDefinitions() []EnvVar {
 	}
 }
 
-// BuildEnvDefinitions returns the set of env vars guaranteed
-// to be available to the build instructions, alongside a description
+// CToolchainBuildEnvDefinitions are environment variables that configure the
+// C toolchain. They are only set when cgo is explicitly enabled, so that
+// otherwise the host's values apply, as they always used to.
+func CToolchainBuildEnvDefinitions() []EnvVar {
+	return []EnvVar{
+		{
+			"CC",
+			"The C compiler, if `CGO_ENABLED` is `1`. Empty means Go's default.",
+			func(c Config) string { return c.Parameters.CC },
+		},
+		{
+			"CXX",
+			"The C++ compiler, if `CGO_ENABLED` is `1`. Empty means Go's default.",
+			func(c Config) string { return c.Parameters.CXX },
+		},
+		{
+			"CGO_CFLAGS",
+			"If `CGO_ENABLED` is `1`, the configured C flags, plus `--sysroot` and `-ffile-prefix-map` for the build root.",
+			func(c Config) string { return c.Parameters.cgoCFlags(c.Paths.WorkDir) },
+		},
+		{
+			"CGO_LDFLAGS",
+			"If `CGO_ENABLED` is `1` and a sysroot is configured, linker flags including `--sysroot`.",
+			func(c Config) string { return c.Parameters.cgoLDFlags() },
+		},
+	}
+}
+
+// BuildEnvDefinitions returns the set of env vars that may be
+// available to the build instructions, alongside a description
 // of each one.
 func BuildEnvDefinitions() []EnvVar {
-	return append(InvariantBuildEnvDefinitions(), BuildSpecificBuildEnvDefinitions()...)
+	bed := append(InvariantBuildEnvDefinitions(), GoVariantBuildEnvDefinitions()...)
+	bed = append(bed, BuildSpecificBuildEnvDefinitions()...)
+	return append(bed, CToolchainBuildEnvDefinitions()...)
 }
@@ -0,0 +1,237 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+)
+
+// Explanation categorises the differences between a primary and verification
+// build result, to help work out why they did not produce identical artifacts.
+type Explanation struct {
+	// Reproduced is true if the zip files are identical.
+	Reproduced bool
+	// Env lists invariant build env vars whose values differ.
+	Env []Difference `json:",omitempty"`
+	// Inputs lists differing product and parameter fields (other than the
+	// revision and source hash) which can be toggled by Bisect.
+	Inputs []Difference `json:",omitempty"`
+	// GoVersion lists differences in the configured Go version, and in the
+	// Go version embedded in each executable (if it's available to inspect).
+	GoVersion []Difference `json:",omitempty"`
+	// Toolchain lists differences in the C toolchain used by cgo builds.
+	Toolchain []Difference `json:",omitempty"`
+	// Tool lists differences in the tool that created each build config.
+	Tool []Difference `json:",omitempty"`
+	// PathLeaks lists host-specific paths found inside either executable.
+	PathLeaks []PathLeak `json:",omitempty"`
+	// Culprits is populated by bisecting, and lists the Inputs which, when
+	// changed alone, reproduce the verification build's zip file.
+	Culprits []string `json:",omitempty"`
+}
+
+// Difference is a single named value that differs between two builds.
+type Difference struct {
+	Name, Primary, Verification string
+}
+
+// Explain compares primary and verification results. Executables are only
+// inspected if they still exist at their original paths.
+func Explain(primary, verification Result) (Explanation, error) {
+	e := Explanation{
+		Reproduced: primary.Zip.SHA256Sum != "" && primary.Zip.SHA256Sum == verification.Zip.SHA256Sum,
+		Env:        envDifferences(primary.Env, verification.Env),
+		Inputs:     inputDifferences(primary.Config, verification.Config),
+		Toolchain:  primary.Toolchain.differences(verification.Toolchain),
+		Tool: differences(
+			diff("Name", primary.Config.Tool.Name, verification.Config.Tool.Name),
+			diff("Version", primary.Config.Tool.Version, verification.Config.Tool.Version),
+			diff("Revision", primary.Config.Tool.Revision, verification.Config.Tool.Revision),
+		),
+	}
+
+	pGo, err := embeddedGoVersion(primary.Executable.OriginalPath)
+	if err != nil {
+		return e, err
+	}
+	vGo, err := embeddedGoVersion(verification.Executable.OriginalPath)
+	if err != nil {
+		return e, err
+	}
+	e.GoVersion = differences(
+		diff("Configured", primary.Config.Parameters.GoVersion, verification.Config.Parameters.GoVersion),
+		diff("Embedded", pGo, vGo),
+	)
+
+	needles := append(hostSpecificPaths(primary.Config), hostSpecificPaths(verification.Config)...)
+	for _, b := range []struct {
+		name string
+		r    Result
+	}{{"primary", primary}, {"verification", verification}} {
+		leaks, err := scanFileForPaths(b.r.Executable.OriginalPath, needles)
+		if err != nil {
+			return e, err
+		}
+		if leaks == nil {
+			// The executable isn't available here, so fall back to
+			// what was recorded at build time.
+			leaks = b.r.PathLeaks
+		}
+		for _, l := range leaks {
+			l.Build = b.name
+			e.PathLeaks = append(e.PathLeaks, l)
+		}
+	}
+	return e, nil
+}
+
+// embeddedGoVersion returns the Go version recorded in the executable at path,
+// or an empty string if there is no such executable, or it's not a Go binary.
+func embeddedGoVersion(path string) (string, error) {
+	if exists, err := fs.FileExists(path); err != nil || !exists {
+		return "", err
+	}
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	return parseGoVersion(info.GoVersion), nil
+}
+
+func diff(name, primary, verification string) *Difference {
+	if primary == verification {
+		return nil
+	}
+	return &Difference{Name: name, Primary: primary, Verification: verification}
+}
+
+func differences(ds ...*Difference) []Difference {
+	var out []Difference
+	for _, d := range ds {
+		if d != nil {
+			out = append(out, *d)
+		}
+	}
+	return out
+}
+
+func envDifferences(primary, verification []string) []Difference {
+	p, v := envMap(primary), envMap(verification)
+	var ds []*Difference
+	for _, e := range append(InvariantBuildEnvDefinitions(), GoVariantBuildEnvDefinitions()...) {
+		ds = append(ds, diff(e.Name, p[e.Name], v[e.Name]))
+	}
+	return differences(ds...)
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// untoggleable inputs identify the source code itself, so changing them means
+// building something else entirely.
+var untoggleable = map[string]bool{
+	"Product.Revision":   true,
+	"Product.SourceHash": true,
+}
+
+// inputDifferences lists the string fields of Product and Parameters that differ.
+func inputDifferences(primary, verification Config) []Difference {
+	var ds []*Difference
+	walkStringFields(reflect.ValueOf(primary), reflect.ValueOf(verification), "", func(name string, p, v reflect.Value) {
+		if !untoggleable[name] {
+			ds = append(ds, diff(name, p.String(), v.String()))
+		}
+	}, "Product", "Parameters")
+	return differences(ds...)
+}
+
+// walkStringFields calls f with each pair of exported string fields in p and v,
+// recursing into nested structs. If only is not empty, then only top-level fields
+// named in only are considered.
+func walkStringFields(p, v reflect.Value, prefix string, f func(name string, p, v reflect.Value), only ...string) {
+	t := p.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || (len(only) != 0 && !contains(only, field.Name)) {
+			continue
+		}
+		name := prefix + field.Name
+		switch field.Type.Kind() {
+		case reflect.String:
+			f(name, p.Field(i), v.Field(i))
+		case reflect.Struct:
+			walkStringFields(p.Field(i), v.Field(i), name+".", f)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WithInput returns a copy of c with the named input (as listed by
+// Explanation.Inputs) set to value.
+func (c Config) WithInput(name, value string) (Config, error) {
+	if untoggleable[name] || !(strings.HasPrefix(name, "Product.") || strings.HasPrefix(name, "Parameters.")) {
+		return c, fmt.Errorf("input %q cannot be changed", name)
+	}
+	v := reflect.ValueOf(&c).Elem()
+	for _, part := range strings.Split(name, ".") {
+		if v.Kind() != reflect.Struct {
+			return c, fmt.Errorf("no input named %q", name)
+		}
+		if v = v.FieldByName(part); !v.IsValid() {
+			return c, fmt.Errorf("no input named %q", name)
+		}
+	}
+	if v.Kind() != reflect.String {
+		return c, fmt.Errorf("input %q is not a string", name)
+	}
+	v.SetString(value)
+	return c, nil
+}
+
+// BisectFunc runs a verification build using c and returns its result.
+type BisectFunc func(c Config) (Result, error)
+
+// Bisect re-runs the primary build once per differing input, each time changing
+// only that input to the verification build's value. Any input that causes the
+// build to produce the verification build's zip file is recorded as a culprit.
+func (e *Explanation) Bisect(primary, verification Result, run BisectFunc) error {
+	if primary.Config.Product.IsDirty() {
+		return fmt.Errorf("cannot bisect a dirty build")
+	}
+	e.Culprits = nil
+	for _, d := range e.Inputs {
+		c, err := primary.Config.WithInput(d.Name, d.Verification)
+		if err != nil {
+			return err
+		}
+		r, err := run(c)
+		if err != nil {
+			return fmt.Errorf("bisecting %s: %w", d.Name, err)
+		}
+		if r.Error() == nil && r.Zip.SHA256Sum == verification.Zip.SHA256Sum {
+			e.Culprits = append(e.Culprits, d.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+func TestExplain(t *testing.T) {
+	primary := Result{Config: standardConfig("/primary"), Zip: crt.File{SHA256Sum: "aaa"}}
+	primary.Config.Product.SourceHash = primary.Config.Product.Revision
+	primary.Env = []string{"OS=linux", "ARCH=amd64", "BIN_PATH=/primary/dist/lockbox"}
+
+	verification := primary
+	verification.Config = standardConfig("/verification")
+	verification.Config.Product.SourceHash = verification.Config.Product.Revision
+	verification.Config.Parameters.Arch = "arm64"
+	verification.Config.Parameters.GoVersion = "1.19"
+	verification.Config.Tool.Version = "1.0.0"
+	verification.Env = []string{"OS=linux", "ARCH=arm64", "BIN_PATH=/verification/dist/lockbox"}
+	verification.Zip.SHA256Sum = "bbb"
+
+	got, err := Explain(primary, verification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Explanation{
+		Env: []Difference{
+			{"ARCH", "amd64", "arm64"},
+		},
+		Inputs: []Difference{
+			{"Parameters.GoVersion", "", "1.19"},
+			{"Parameters.Arch", "amd64", "arm64"},
+		},
+		GoVersion: []Difference{
+			{"Configured", "", "1.19"},
+		},
+		Tool: []Difference{
+			{"Version", "", "1.0.0"},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatal(diff)
+	}
+
+	var built []string
+	err = got.Bisect(primary, verification, func(c Config) (Result, error) {
+		built = append(built, c.Parameters.Arch)
+		if c.Parameters.Arch == "arm64" {
+			return verification, nil
+		}
+		return primary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(built, []string{"amd64", "arm64"}); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(got.Culprits, []string{"Parameters.Arch"}); diff != "" {
+		t.Error(diff)
+	}
+}
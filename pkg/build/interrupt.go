@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrorKind categorises why a build failed, where that's not down to the
+// build itself.
+type ErrorKind string
+
+const (
+	// ErrorKindCanceled means the build was cancelled, e.g. by SIGINT.
+	ErrorKindCanceled ErrorKind = "canceled"
+	// ErrorKindTimeout means the build or one of its steps timed out.
+	ErrorKindTimeout ErrorKind = "timeout"
+)
+
+var (
+	// ErrCanceled is wrapped by the errors of cancelled builds.
+	ErrCanceled = errors.New("build canceled")
+	// ErrTimeout is wrapped by the errors of builds that timed out.
+	ErrTimeout = errors.New("build timed out")
+)
+
+// contextSetter is implemented by builds whose commands can be given
+// a context for each step.
+type contextSetter interface {
+	setContext(context.Context)
+}
+
+func (b *core) setContext(ctx context.Context) { b.Settings.context = ctx }
+
+// withTimeout is context.WithTimeout, except zero d means no timeout.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// interruption returns the error kind and error for a step that ran with
+// stepCtx, derived from buildCtx, derived from baseCtx. It returns an empty
+// kind and nil error if the step wasn't interrupted.
+func (s *Settings) interruption(baseCtx, buildCtx, stepCtx context.Context) (ErrorKind, error) {
+	switch {
+	case stepCtx.Err() == nil:
+		return "", nil
+	case baseCtx.Err() != nil:
+		return ErrorKindCanceled, ErrCanceled
+	case buildCtx.Err() != nil:
+		return ErrorKindTimeout, fmt.Errorf("%w: the build took longer than %s", ErrTimeout, s.timeout)
+	default:
+		return ErrorKindTimeout, fmt.Errorf("%w: this step took longer than %s", ErrTimeout, s.stepTimeout)
+	}
+}
+
+// sleep is like time.Sleep, but returns early with an error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
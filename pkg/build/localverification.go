@@ -5,6 +5,7 @@ package build
 
 import (
 	"os"
+	"path/filepath"
 	"time"
 
 	cp "github.com/otiai10/copy"
@@ -24,14 +25,21 @@ func NewLocalVerification(primaryRoot string, startAfter time.Time, cfg Config,
 	if err != nil {
 		return nil, err
 	}
-	if err := core.ChangeToVerificationRoot(); err != nil {
-		return nil, err
-	}
-	return &LocalVerification{
+	lv := &LocalVerification{
 		core:        core,
 		primaryRoot: primaryRoot,
 		startAfter:  startAfter,
-	}, nil
+	}
+	// Git worktrees always contain the whole repository.
+	if lv.sourceStrategy == SourceGit {
+		if err := lv.useRepoRoot(); err != nil {
+			return nil, err
+		}
+	}
+	if err := core.ChangeToVerificationRoot(); err != nil {
+		return nil, err
+	}
+	return lv, nil
 }
 
 func (lv *LocalVerification) Kind() string { return "local verification" }
@@ -40,24 +48,61 @@ func (lv *LocalVerification) Steps() []Step {
 
 	var sleepTime time.Duration
 	now := time.Now()
-	if lv.startAfter.After(now) {
-		sleepTime = lv.startAfter.Sub(now)
+	startAfter := lv.startAfter
+	if variance := lv.activeVariance(); variance != nil {
+		startAfter = startAfter.Add(variance.clockOffset())
 	}
-	pPath := lv.primaryRoot
-	vPath := lv.Config().Paths.WorkDir
+	if startAfter.After(now) {
+		sleepTime = startAfter.Sub(now)
+	}
+	vPath := lv.sourceRoot(lv.Config().Paths.WorkDir)
 
-	pre := []Step{
+	pre := lv.copySteps(vPath)
+	if lv.sourceStrategy == SourceGit {
+		pre = lv.gitSteps(vPath)
+	}
+	pre = append(pre, newStep("waiting until the stagger time has elapsed", func() error {
+		return sleep(lv.Settings.context, sleepTime)
+	}))
+
+	return append(pre, lv.core.Steps()...)
+}
+
+// copySteps copy the primary root to vPath.
+func (lv *LocalVerification) copySteps(vPath string) []Step {
+	pPath := lv.primaryRoot
+	variance := lv.activeVariance()
+	skip := lv.skipOutputs(vPath)
+	return []Step{
 		newStep("ensuring new empty directory to run build in", func() error {
 			return os.RemoveAll(vPath)
 		}),
 		newStep("copying primary build root dir to temp dir", func() error {
-			return cp.Copy(pPath, vPath)
-		}),
-		newStep("waiting until the stagger time has elapsed", func() error {
-			time.Sleep(sleepTime)
-			return nil
+			if variance != nil && variance.ShuffledCopy {
+				return shuffledCopy(pPath, vPath, variance.Seed, skip)
+			}
+			return cp.Copy(pPath, vPath, cp.Options{Skip: func(src string) (bool, error) {
+				rel, err := filepath.Rel(pPath, src)
+				return skip(rel), err
+			}})
 		}),
 	}
+}
 
-	return append(pre, lv.core.Steps()...)
+// skipOutputs returns a func reporting whether to skip copying a path,
+// relative to the root, given the root the verification build copies to.
+// If the primary build is running at the same time, its outputs are
+// skipped because they may change while being copied.
+func (lv *LocalVerification) skipOutputs(root string) func(rel string) bool {
+	if !lv.concurrent {
+		return func(string) bool { return false }
+	}
+	paths := lv.Config().Paths
+	skipped := map[string]bool{}
+	for _, dir := range []string{paths.TargetDir(), paths.ZipDir(), paths.MetaDir} {
+		if rel, err := filepath.Rel(root, dir); err == nil {
+			skipped[rel] = true
+		}
+	}
+	return func(rel string) bool { return skipped[rel] }
 }
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"sync"
+
+	"github.com/hashicorp/actions-go-build/internal/log"
+)
+
+// logAttrs are the fields added to structured log events. They're updated as
+// the build progresses, and are shared by copies of the Settings they belong to.
+type logAttrs struct {
+	mu                              sync.Mutex
+	logger, product, configID, step string
+}
+
+// wrap returns f, but with the current attrs added to each event.
+func (a *logAttrs) wrap(f log.Func) log.Func {
+	if !log.IsStructured() {
+		return f
+	}
+	return func(format string, args ...any) {
+		log.With(f, a.list()...)(format, args...)
+	}
+}
+
+func (a *logAttrs) list() []any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var kv []any
+	for _, f := range []struct{ k, v string }{
+		{"logger", a.logger},
+		{"product", a.product},
+		{"config_id", a.configID},
+		{"step", a.step},
+	} {
+		if f.v != "" {
+			kv = append(kv, f.k, f.v)
+		}
+	}
+	return kv
+}
+
+// setBuild sets the product and config ID from c.
+func (a *logAttrs) setBuild(c Config) {
+	id := c.ID()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.product, a.configID = c.Product.Name, id
+}
+
+func (a *logAttrs) setStep(step string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.step = step
+}
+
+// logAttrsGetter is implemented by builds whose log events can be annotated
+// with the current step.
+type logAttrsGetter interface {
+	getLogAttrs() *logAttrs
+}
+
+func (s *Settings) getLogAttrs() *logAttrs { return s.logAttrs }
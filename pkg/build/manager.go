@@ -4,7 +4,10 @@
 package build
 
 import (
+	"context"
 	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Manager is responsible for orchestrating the running of builds.
@@ -43,28 +46,69 @@ func (bm *Manager) ResultFromCache() (Result, bool, error) {
 // from the attempt to load from cache, so to check if the build failed or not you still need
 // to call the Result's Error method.
 func (bm *Manager) Result() (Result, error) {
+	c := bm.Build().Config()
+	ctx, span := startSpan(bm.context, "build.Manager.Result",
+		append(c.spanAttrs(), attribute.Bool("build.verification", bm.Build().IsVerification()))...)
+	prev := bm.runner.context
+	bm.runner.setContext(ctx)
+	defer bm.runner.setContext(prev)
+
+	r, cache, err := bm.result()
+	span.SetAttributes(attribute.String("build.cache", cache))
+	if err == nil {
+		span.SetAttributes(r.spanAttrs()...)
+		endSpan(span, r.Error())
+	} else {
+		endSpan(span, err)
+	}
+	return r, err
+}
+
+// Cache statuses recorded in traces.
+const (
+	cacheHit      = "hit"
+	cacheMiss     = "miss"
+	cacheBypassed = "bypassed"
+)
+
+// result is Result, but also returns the cache status.
+func (bm *Manager) result() (Result, string, error) {
 	bm.Debug("Beginning getting result.")
 	if bm.Build().IsVerification() {
-		return bm.runBuild("Verification builds never load from cache")
+		r, err := bm.runBuild("Verification builds never load from cache")
+		return r, cacheBypassed, err
 	}
 	if bm.forceRebuild {
-		return bm.runBuild("Force-rebuild on")
+		r, err := bm.runBuild("Force-rebuild on")
+		return r, cacheBypassed, err
+	}
+	if bm.auditInputs {
+		r, err := bm.runBuild("Auditing inputs")
+		return r, cacheBypassed, err
 	}
 	bm.Debug("Inspecting cache.")
 	r, cached, err := bm.ResultFromCache()
 	if err != nil {
-		return r, fmt.Errorf("inspecting cache: %w", err)
+		return r, cacheMiss, fmt.Errorf("inspecting cache: %w", err)
 	}
 	if cached {
 		bm.Log("Loaded build result from cache; SourceID: %s; Dirty: %t", r.Config.Product.SourceHash, r.Config.Product.IsDirty())
-		return r, nil
+		return r, cacheHit, nil
 	}
-	return bm.runBuild("No build result available in cache")
+	r, err = bm.runBuild("No build result available in cache")
+	return r, cacheMiss, err
 }
 
+// setContext sets the context the build runs in.
+func (bm *Manager) setContext(ctx context.Context) { bm.context = ctx }
+
 func (bm *Manager) runBuild(why string) (Result, error) {
 	bm.Log("%s; Running a fresh build...", why)
 	result := bm.runner.Run()
+	if result.ErrorKind != "" {
+		bm.Debug("Not caching interrupted build result.")
+		return result, nil
+	}
 	cachePath, err := result.Save(bm.Build().IsVerification())
 	if err != nil {
 		return result, err
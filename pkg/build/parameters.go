@@ -5,8 +5,11 @@ package build
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/actions-go-build/pkg/crt"
@@ -27,17 +30,166 @@ type Parameters struct {
 	Arch string `env:"ARCH"`
 	// ZipName is the name of the zip file to create.
 	ZipName string `env:"ZIP_NAME"`
+
+	// The fields below are omitted from JSON when empty, so that adding them
+	// didn't change the cache key of existing builds.
+
+	// Tags are the Go build tags, comma-separated.
+	Tags string `env:"BUILD_TAGS" json:",omitempty"`
+	// CGOEnabled is "1" to enable cgo, "0" to disable it, or empty for
+	// Go's default. It's read from BUILD_CGO_ENABLED rather than CGO_ENABLED,
+	// like GOARM and GOAMD64 below, so that a CGO_ENABLED, GOARM, or GOAMD64
+	// already set on the runner doesn't change the default zip name.
+	CGOEnabled string `env:"BUILD_CGO_ENABLED" json:",omitempty"`
+	// GOARM is the ARM version to target. It's cleared unless Arch is arm.
+	GOARM string `env:"BUILD_GOARM" json:",omitempty"`
+	// GOAMD64 is the AMD64 microarchitecture level to target. It's cleared
+	// unless Arch is amd64.
+	GOAMD64 string `env:"BUILD_GOAMD64" json:",omitempty"`
+	// MainPackage is the package to build. Defaults to the current directory.
+	MainPackage string `env:"MAIN_PACKAGE" json:",omitempty"`
+	// LDFlags are extra linker flags, passed after the default ones.
+	LDFlags string `env:"EXTRA_LDFLAGS" json:",omitempty"`
+
+	// The C toolchain fields below only apply when CGOEnabled is "1",
+	// and are cleared otherwise. Like CGOEnabled, they're read from BUILD_
+	// variables, so that a CC or CGO_CFLAGS set on the runner doesn't change
+	// the build. They're passed to the build instructions as CC, CXX, and
+	// CGO_CFLAGS.
+
+	// CC is the C compiler command. Defaults to the value of the
+	// CC_FOR_<OS>_<ARCH> environment variable, or to zig cc if Zig is set.
+	CC string `env:"BUILD_CC" json:",omitempty"`
+	// CXX is the C++ compiler command. Defaults like CC.
+	CXX string `env:"BUILD_CXX" json:",omitempty"`
+	// Zig is the zig command to use as the C and C++ compiler driver,
+	// targeting OS and Arch.
+	Zig string `env:"BUILD_ZIG" json:",omitempty"`
+	// Sysroot is passed to the C compiler and linker as --sysroot.
+	Sysroot string `env:"BUILD_SYSROOT" json:",omitempty"`
+	// CGOCFlags are the flags passed to the C compiler. Defaults to "-O2 -g",
+	// like Go. An -ffile-prefix-map flag for the build root is always added.
+	CGOCFlags string `env:"BUILD_CGO_CFLAGS" json:",omitempty"`
 }
 
 func (bp Parameters) Init(p crt.Product) (Parameters, error) {
-	return bp.trimSpace().setDefaults(p)
+	return bp.InitWithOrigins(p, nil)
+}
+
+// InitWithOrigins is like Init, but also records where each defaulted value
+// came from in o.
+func (bp Parameters) InitWithOrigins(p crt.Product, o crt.Origins) (Parameters, error) {
+	return bp.trimSpace().setDefaults(p, o)
 }
 
 func (bp Parameters) trimSpace() Parameters {
-	trim(&bp.GoVersion, &bp.Instructions, &bp.OS, &bp.Arch, &bp.ZipName)
+	trim(&bp.GoVersion, &bp.Instructions, &bp.OS, &bp.Arch, &bp.ZipName,
+		&bp.Tags, &bp.CGOEnabled, &bp.GOARM, &bp.GOAMD64, &bp.MainPackage, &bp.LDFlags,
+		&bp.CC, &bp.CXX, &bp.Zig, &bp.Sysroot, &bp.CGOCFlags)
 	return bp
 }
 
+var (
+	tagPattern     = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	goarmPattern   = regexp.MustCompile(`^[5-7](,(softfloat|hardfloat))?$`)
+	goamd64Pattern = regexp.MustCompile(`^v[1-4]$`)
+	// doubleQuoteSpecial matches the characters that aren't literal inside
+	// double quotes in a bash script.
+	doubleQuoteSpecial = regexp.MustCompile("[\"$`\\\\]")
+)
+
+// normalize validates the build variant parameters and puts them in a
+// canonical form, so that equivalent values produce the same cache key.
+// It must be called after OS and Arch are defaulted.
+func (bp Parameters) normalize(o crt.Origins) (Parameters, error) {
+	if bp.Tags != "" {
+		seen := map[string]bool{}
+		var tags []string
+		for _, t := range strings.FieldsFunc(bp.Tags, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if !tagPattern.MatchString(t) {
+				return bp, fmt.Errorf("invalid build tag %q", t)
+			}
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+		sort.Strings(tags)
+		bp.Tags = strings.Join(tags, ",")
+	}
+	switch strings.ToLower(bp.CGOEnabled) {
+	case "":
+	case "1", "true":
+		bp.CGOEnabled = "1"
+	case "0", "false":
+		bp.CGOEnabled = "0"
+	default:
+		return bp, fmt.Errorf("BUILD_CGO_ENABLED must be 1, 0, true, or false, not %q", bp.CGOEnabled)
+	}
+	if bp.GOARM != "" && bp.Arch != "arm" {
+		o.Warn("BUILD_GOARM", "ignored because ARCH is %s, not arm", bp.Arch)
+		bp.GOARM = ""
+	}
+	if bp.GOARM != "" && !goarmPattern.MatchString(bp.GOARM) {
+		return bp, fmt.Errorf("BUILD_GOARM must be 5, 6, or 7, optionally followed by ,softfloat or ,hardfloat, not %q", bp.GOARM)
+	}
+	if bp.GOAMD64 != "" && bp.Arch != "amd64" {
+		o.Warn("BUILD_GOAMD64", "ignored because ARCH is %s, not amd64", bp.Arch)
+		bp.GOAMD64 = ""
+	}
+	if bp.GOAMD64 != "" && !goamd64Pattern.MatchString(bp.GOAMD64) {
+		return bp, fmt.Errorf("BUILD_GOAMD64 must be one of v1, v2, v3, or v4, not %q", bp.GOAMD64)
+	}
+	// MainPackage and LDFlags are double-quoted in the default instructions.
+	if doubleQuoteSpecial.MatchString(bp.MainPackage) {
+		return bp, fmt.Errorf("MAIN_PACKAGE must not contain \", $, `, or \\, not %q", bp.MainPackage)
+	}
+	if doubleQuoteSpecial.MatchString(bp.LDFlags) {
+		return bp, fmt.Errorf("EXTRA_LDFLAGS must not contain \", $, `, or \\, not %q", bp.LDFlags)
+	}
+	return bp.setCToolchain(o)
+}
+
+// setCToolchain clears the C toolchain unless cgo is explicitly enabled,
+// and otherwise sets the default compilers for the target.
+func (bp Parameters) setCToolchain(o crt.Origins) (Parameters, error) {
+	if bp.CGOEnabled != "1" {
+		for _, f := range []struct {
+			name  string
+			value *string
+		}{{"BUILD_CC", &bp.CC}, {"BUILD_CXX", &bp.CXX}, {"BUILD_ZIG", &bp.Zig}, {"BUILD_SYSROOT", &bp.Sysroot}, {"BUILD_CGO_CFLAGS", &bp.CGOCFlags}} {
+			if *f.value != "" {
+				o.Warn(f.name, "ignored because BUILD_CGO_ENABLED is not 1")
+				*f.value = ""
+			}
+		}
+		return bp, nil
+	}
+	for _, c := range []struct {
+		key, name, zigCommand string
+		value                 *string
+	}{{"BUILD_CC", "CC", "cc", &bp.CC}, {"BUILD_CXX", "CXX", "c++", &bp.CXX}} {
+		if *c.value != "" {
+			continue
+		}
+		env := fmt.Sprintf("%s_FOR_%s_%s", c.name, bp.OS, bp.Arch)
+		if *c.value = os.Getenv(env); *c.value != "" {
+			o.Set(c.key, "environment variable %s", env)
+			continue
+		}
+		if bp.Zig == "" {
+			continue
+		}
+		target, err := zigTarget(bp.OS, bp.Arch)
+		if err != nil {
+			return bp, err
+		}
+		*c.value = fmt.Sprintf("%s %s -target %s", bp.Zig, c.zigCommand, target)
+		o.Set(c.key, "zig %s for %s/%s", c.zigCommand, bp.OS, bp.Arch)
+	}
+	return bp, nil
+}
+
 func getInstalledGoVersion() (string, error) {
 	got, err := exec.Command("go", "env", "GOVERSION").Output()
 	if err != nil {
@@ -53,21 +205,33 @@ func parseGoVersion(raw string) string {
 	return raw
 }
 
-func (bp Parameters) setDefaults(p crt.Product) (Parameters, error) {
+func (bp Parameters) setDefaults(p crt.Product, o crt.Origins) (Parameters, error) {
 	if bp.GoVersion == "" {
 		var err error
 		if bp.GoVersion, err = getInstalledGoVersion(); err != nil {
 			return bp, err
 		}
+		o.Set("GO_VERSION", "go env GOVERSION")
 	}
 	if bp.OS == "" {
 		bp.OS = runtime.GOOS
+		o.Set("OS", "host OS")
 	}
 	if bp.Arch == "" {
 		bp.Arch = runtime.GOARCH
+		o.Set("ARCH", "host architecture")
+	}
+	bp, err := bp.normalize(o)
+	if err != nil {
+		return bp, err
 	}
 	if bp.ZipName == "" {
 		bp.ZipName = bp.defaultZipName(p)
+		if bp.variant() == "" {
+			o.Set("ZIP_NAME", "product name, version, OS, and architecture")
+		} else {
+			o.Set("ZIP_NAME", "product name, version, OS, architecture, and build variant")
+		}
 	}
 	if bp.Instructions == "" {
 		var err error
@@ -75,12 +239,43 @@ func (bp Parameters) setDefaults(p crt.Product) (Parameters, error) {
 		if err != nil {
 			return bp, err
 		}
+		o.Set("INSTRUCTIONS", "default instructions for Go %s", bp.GoVersion)
 	}
 	return bp, nil
 }
 
+// Platform is the target OS and Arch as "os/arch", followed by the variant
+// if there is one, e.g. "linux/amd64_v3_nocgo".
+func (bp Parameters) Platform() string {
+	return bp.OS + "/" + bp.Arch + bp.variant()
+}
+
 func (bp Parameters) defaultZipName(p crt.Product) string {
-	return fmt.Sprintf("%s_%s_%s_%s.zip", p.Name, p.Version.Full, bp.OS, bp.Arch)
+	return fmt.Sprintf("%s_%s_%s_%s%s.zip", p.Name, p.Version.Full, bp.OS, bp.Arch, bp.variant())
+}
+
+// variant describes the microarchitecture level, cgo setting, and build tags,
+// for use in the zip name. For example, GOAMD64=v3, CGO_ENABLED=0, and
+// BUILD_TAGS=netgo,osusergo give "_v3_nocgo_netgo-osusergo", and GOARM=7
+// gives "_v7". It's empty if none are set.
+func (bp Parameters) variant() string {
+	var sb strings.Builder
+	if bp.GOARM != "" {
+		sb.WriteString("_v" + strings.ReplaceAll(bp.GOARM, ",", "-"))
+	}
+	if bp.GOAMD64 != "" {
+		sb.WriteString("_" + bp.GOAMD64)
+	}
+	switch bp.CGOEnabled {
+	case "1":
+		sb.WriteString("_cgo")
+	case "0":
+		sb.WriteString("_nocgo")
+	}
+	if bp.Tags != "" {
+		sb.WriteString("_" + strings.ReplaceAll(bp.Tags, ",", "-"))
+	}
+	return sb.String()
 }
 
 func (bp Parameters) defaultInstructions(p crt.Product) (string, error) {
@@ -98,9 +293,21 @@ func (bp Parameters) defaultInstructions(p crt.Product) (string, error) {
 		// reproducibility when buildvcs=true.
 		flags = append(flags, "-buildvcs=false")
 	}
+	if bp.Tags != "" {
+		flags = append(flags, "-tags", bp.Tags)
+	}
+	var ldFlags []string
 	if p.Module != "" {
-		ldFlags := fmt.Sprintf(`"%s"`, defaultLDFlags(p))
-		flags = append(flags, "-ldflags", ldFlags)
+		ldFlags = append(ldFlags, defaultLDFlags(p))
+	}
+	if bp.LDFlags != "" {
+		ldFlags = append(ldFlags, bp.LDFlags)
+	}
+	if len(ldFlags) != 0 {
+		flags = append(flags, "-ldflags", fmt.Sprintf(`"%s"`, strings.Join(ldFlags, " ")))
+	}
+	if bp.MainPackage != "" {
+		flags = append(flags, fmt.Sprintf(`"%s"`, bp.MainPackage))
 	}
 	return strings.Join(flags, " "), nil
 }
@@ -4,7 +4,10 @@
 package build
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/hashicorp/actions-go-build/pkg/crt"
 )
 
 func TestParseGoVersion(t *testing.T) {
@@ -38,3 +41,105 @@ func TestParseGoVersion(t *testing.T) {
 	}
 
 }
+
+func TestParameters_Init_variant(t *testing.T) {
+
+	product := crt.Product{Name: "lockbox", Version: crt.ProductVersion{Full: "1.2.3"}}
+	base := Parameters{GoVersion: "1.18", OS: "linux", Arch: "amd64"}
+	with := func(f func(*Parameters)) Parameters {
+		bp := base
+		f(&bp)
+		return bp
+	}
+	const goBuild = `go build -o "$BIN_PATH" -trimpath -buildvcs=false`
+
+	cases := []struct {
+		desc                  string
+		in                    Parameters
+		wantZip, wantCommand  string
+		wantTags, wantGOAMD64 string
+	}{
+		{
+			"defaults", base,
+			"lockbox_1.2.3_linux_amd64.zip", goBuild, "", "",
+		},
+		{
+			"all set",
+			with(func(bp *Parameters) {
+				bp.Tags = " osusergo netgo,netgo "
+				bp.CGOEnabled = "false"
+				bp.GOAMD64 = "v3"
+				bp.MainPackage = "./cmd/lockbox"
+				bp.LDFlags = "-s -w"
+			}),
+			"lockbox_1.2.3_linux_amd64_v3_nocgo_netgo-osusergo.zip",
+			goBuild + ` -tags netgo,osusergo -ldflags "-s -w" "./cmd/lockbox"`,
+			"netgo,osusergo", "v3",
+		},
+		{
+			"GOAMD64 ignored for arm64",
+			with(func(bp *Parameters) {
+				bp.Arch = "arm64"
+				bp.GOAMD64 = "v3"
+			}),
+			"lockbox_1.2.3_linux_arm64.zip", goBuild, "", "",
+		},
+		{
+			"GOARM",
+			with(func(bp *Parameters) {
+				bp.Arch = "arm"
+				bp.GOARM = "6"
+			}),
+			"lockbox_1.2.3_linux_arm_v6.zip", goBuild, "", "",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			got, err := c.in.Init(product)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ZipName != c.wantZip {
+				t.Errorf("got zip name %q; want %q", got.ZipName, c.wantZip)
+			}
+			if got.Instructions != c.wantCommand {
+				t.Errorf("got instructions %q; want %q", got.Instructions, c.wantCommand)
+			}
+			if got.Tags != c.wantTags {
+				t.Errorf("got tags %q; want %q", got.Tags, c.wantTags)
+			}
+			if got.GOAMD64 != c.wantGOAMD64 {
+				t.Errorf("got GOAMD64 %q; want %q", got.GOAMD64, c.wantGOAMD64)
+			}
+		})
+	}
+}
+
+func TestParameters_Init_variant_err(t *testing.T) {
+
+	cases := []struct {
+		desc string
+		in   Parameters
+		want string
+	}{
+		{"bad tag", Parameters{Arch: "amd64", Tags: "a;b"}, `invalid build tag "a;b"`},
+		{"bad cgo", Parameters{Arch: "amd64", CGOEnabled: "yes"}, "BUILD_CGO_ENABLED must be"},
+		{"bad GOARM", Parameters{Arch: "arm", GOARM: "8"}, "BUILD_GOARM must be"},
+		{"bad GOAMD64", Parameters{Arch: "amd64", GOAMD64: "v5"}, "BUILD_GOAMD64 must be"},
+		{"quote in main package", Parameters{Arch: "amd64", MainPackage: `./cmd/"x`}, "MAIN_PACKAGE must not contain"},
+		{"command in ldflags", Parameters{Arch: "amd64", LDFlags: "-X main.x=$(id)"}, "EXTRA_LDFLAGS must not contain"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			c.in.GoVersion, c.in.OS = "1.18", "linux"
+			_, err := c.in.Init(crt.Product{})
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("got error %v; want error containing %q", err, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/internal/scan"
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+)
+
+// PathLeak records a build-specific path found inside a build artifact.
+// These usually break reproducibility, and are most often caused by
+// forgetting to pass -trimpath to go build.
+type PathLeak struct {
+	// Build is either "primary" or "verification". It is only set when
+	// comparing builds, e.g. by Explain.
+	Build string `json:",omitempty"`
+	// File is the path to the file that was scanned.
+	File string
+	// Entry is the name of the entry inside File, when File is a zip.
+	Entry string `json:",omitempty"`
+	// Path is the leaked path.
+	Path string
+	// Offsets are the byte offsets where Path was found in File (or Entry).
+	Offsets []int64
+}
+
+func (l PathLeak) String() string {
+	where := l.File
+	if l.Entry != "" {
+		where = fmt.Sprintf("%s (entry %s)", l.File, l.Entry)
+	}
+	return fmt.Sprintf("%q found in %s at offsets %s", l.Path, where, formatOffsets(l.Offsets))
+}
+
+func formatOffsets(offsets []int64) string {
+	s := make([]string, len(offsets))
+	for i, o := range offsets {
+		s[i] = fmt.Sprintf("0x%x", o)
+	}
+	return strings.Join(s, ", ")
+}
+
+// errPathLeaks is returned when path leaks are found and the build is strict about them.
+func errPathLeaks(leaks []PathLeak) error {
+	list := make([]string, len(leaks))
+	for i, l := range leaks {
+		list[i] = l.String()
+	}
+	return fmt.Errorf("build-specific paths found in build artifacts:\n%s", strings.Join(list, "\n"))
+}
+
+// hostSpecificPaths returns the paths that must not appear in build artifacts built
+// from c on this machine. Paths that are inside other paths in the list are omitted,
+// since finding those would also mean finding their parent. Any extra paths are
+// treated the same way.
+func hostSpecificPaths(c Config, extra ...string) []string {
+	candidates := []string{
+		c.Paths.WorkDir,
+		c.Paths.TargetDir(),
+		c.Paths.ZipDir(),
+		c.Paths.MetaDir,
+		os.Getenv("HOME"),
+		goPath(),
+	}
+	candidates = append(candidates, extra...)
+	if c.Tool.Name != "" {
+		// The root of all temporary build directories, including verification builds.
+		candidates = append(candidates, filepath.Join(TempDirFunc(), c.Tool.Name))
+	}
+	var paths []string
+	for _, p := range candidates {
+		if p = filepath.Clean(p); filepath.IsAbs(p) && p != string(filepath.Separator) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	var out []string
+	for _, p := range paths {
+		if n := len(out); n != 0 && (p == out[n-1] || strings.HasPrefix(p, out[n-1]+string(filepath.Separator))) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// goPath returns the GOPATH, or an empty string if it can't be determined.
+func goPath() string {
+	if gp := os.Getenv("GOPATH"); gp != "" {
+		return gp
+	}
+	out, err := exec.Command("go", "env", "GOPATH").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// scanFileForPaths returns nil without error if file does not exist.
+func scanFileForPaths(file string, paths []string) ([]PathLeak, error) {
+	if exists, err := fs.FileExists(file); err != nil || !exists {
+		return nil, err
+	}
+	hits, err := scan.File(file, paths...)
+	if err != nil {
+		return nil, err
+	}
+	leaks := []PathLeak{}
+	for _, h := range hits {
+		leaks = append(leaks, PathLeak{File: file, Path: h.Needle, Offsets: h.Offsets})
+	}
+	return leaks, nil
+}
+
+// scanZipForPaths scans the uncompressed contents of each entry in the zip
+// file, apart from entries whose SHA256 digest is skipSHA256, such as the
+// executable, which has already been scanned by itself.
+func scanZipForPaths(file string, paths []string, skipSHA256 string) ([]PathLeak, error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var leaks []PathLeak
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.New()
+		hits, err := scan.Reader(io.TeeReader(rc, sum), paths...)
+		if closeErr := rc.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hex.EncodeToString(sum.Sum(nil)) == skipSHA256 {
+			continue
+		}
+		for _, h := range hits {
+			leaks = append(leaks, PathLeak{File: file, Entry: f.Name, Path: h.Needle, Offsets: h.Offsets})
+		}
+	}
+	return leaks, nil
+}
+
+// findPathLeaks scans the executable and zip file for host-specific paths.
+// The copy of the executable in the zip isn't scanned again, so each of its
+// leaks is only reported once. exeSHA256 is the executable's digest.
+func findPathLeaks(c Config, exeSHA256 string, extra ...string) ([]PathLeak, error) {
+	paths := hostSpecificPaths(c, extra...)
+	binLeaks, err := scanFileForPaths(c.Paths.BinPath, paths)
+	if err != nil {
+		return nil, err
+	}
+	zipLeaks, err := scanZipForPaths(c.Paths.ZipPath, paths, exeSHA256)
+	if err != nil {
+		return nil, err
+	}
+	return append(binLeaks, zipLeaks...), nil
+}
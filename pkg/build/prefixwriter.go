@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// outputMu serialises writes by all prefixWriters, so that lines from
+// builds running at the same time don't get mixed up.
+var outputMu sync.Mutex
+
+// prefixWriter writes each complete line written to it to w, with prefix.
+// Incomplete lines are held until they're completed or flushed.
+type prefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte(prefix + ": ")}
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.buf = append(pw.buf, p...)
+	i := bytes.LastIndexByte(pw.buf, '\n')
+	if i == -1 {
+		return len(p), nil
+	}
+	if err := pw.write(pw.buf[:i+1]); err != nil {
+		return 0, err
+	}
+	pw.buf = append(pw.buf[:0], pw.buf[i+1:]...)
+	return len(p), nil
+}
+
+// Flush writes any incomplete line, ending it with a newline.
+func (pw *prefixWriter) Flush() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if len(pw.buf) == 0 {
+		return nil
+	}
+	err := pw.write(append(pw.buf, '\n'))
+	pw.buf = pw.buf[:0]
+	return err
+}
+
+// write writes lines, which must end with a newline, to w in one go.
+func (pw *prefixWriter) write(lines []byte) error {
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) != 0 {
+			out.Write(pw.prefix)
+			out.Write(line)
+		}
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	_, err := pw.w.Write(out.Bytes())
+	return err
+}
+
+// flushOutputs writes any incomplete lines held by prefixed outputs.
+func (s *Settings) flushOutputs() {
+	for _, w := range []io.Writer{s.stdout, s.stderr} {
+		if pw, ok := w.(*prefixWriter); ok {
+			if err := pw.Flush(); err != nil {
+				s.Debug("Flushing output: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	a := newPrefixWriter(&buf, "a")
+	b := newPrefixWriter(&buf, "b")
+
+	fmt.Fprint(a, "one")
+	fmt.Fprint(b, "two\nthr")
+	fmt.Fprint(a, " line\nfour\n")
+	fmt.Fprint(b, "ee")
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "b: two\na: one line\na: four\nb: three\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
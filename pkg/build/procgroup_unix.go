@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows
+
+package build
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup runs cmd in its own process group, so that when its
+// context is done, the whole group can be asked to exit with SIGTERM, and
+// then killed with SIGKILL if it's still running after grace.
+//
+// The returned function must be called once cmd has been waited for. If cmd
+// was cancelled, it kills whatever is left of the group straight away, since
+// background processes that ignored SIGTERM can outlive the group leader. The
+// group ID can't be reused while any of them are still running.
+func setProcessGroup(cmd *exec.Cmd, grace time.Duration) (reap func()) {
+	var (
+		mu   sync.Mutex
+		pgid int
+		kill *time.Timer
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		pgid = cmd.Process.Pid
+		kill = time.AfterFunc(grace, func() { _ = syscall.Kill(-pgid, syscall.SIGKILL) })
+		return syscall.Kill(-pgid, syscall.SIGTERM)
+	}
+	// Don't wait forever for background processes holding stdout or stderr open.
+	cmd.WaitDelay = grace + time.Second
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if kill == nil {
+			return
+		}
+		kill.Stop()
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+}
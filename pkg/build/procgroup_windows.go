@@ -0,0 +1,18 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package build
+
+import (
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup just sets cmd's wait delay, because on Windows the
+// default cancellation already kills the process immediately.
+func setProcessGroup(cmd *exec.Cmd, grace time.Duration) (reap func()) {
+	cmd.WaitDelay = grace
+	return func() {}
+}
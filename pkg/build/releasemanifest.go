@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+// ReproStatus is whether an artifact in a release was reproduced.
+type ReproStatus string
+
+const (
+	Reproduced    ReproStatus = "reproduced"
+	NotReproduced ReproStatus = "not reproduced"
+	// Unverified means only build results, and no verification results,
+	// were found for the artifact.
+	Unverified ReproStatus = "unverified"
+)
+
+// ReleaseBuild is a build result read from the file called Name.
+// Verification is set if it was read from a verification result.
+type ReleaseBuild struct {
+	Name         string
+	Result       *Result
+	Verification *VerificationResult
+}
+
+// ReleaseManifest describes every artifact of a single product version.
+type ReleaseManifest struct {
+	Product    string
+	Version    string
+	Repository string
+	Revision   string
+	SourceHash string
+	GoVersion  string
+	Tool       crt.Tool
+	// Artifacts are sorted by platform.
+	Artifacts []ReleaseArtifact
+	// ExpectedPlatforms and MissingPlatforms are platforms in the same form
+	// as ReleaseArtifact.Platform.
+	ExpectedPlatforms []string `json:",omitempty"`
+	MissingPlatforms  []string `json:",omitempty"`
+	// PublicKey is the base64-encoded Ed25519 key the manifest is signed
+	// with, if it's signed. The signature is stored alongside the manifest.
+	PublicKey    string `json:",omitempty"`
+	ErrorMessage string `json:",omitempty"`
+}
+
+// ReleaseArtifact is the zip built for one platform.
+type ReleaseArtifact struct {
+	// Platform is "os/arch", followed by the variant if there is one, e.g.
+	// "linux/amd64_v3", so each variant of a platform is its own artifact.
+	Platform     string
+	Zip          ReleaseFile
+	Executable   ReleaseFile
+	Status       ReproStatus
+	ErrorMessage string `json:",omitempty"`
+	// Results are the names of the results describing the artifact.
+	Results []string
+}
+
+// ReleaseFile is a crt.File without the build-specific path.
+type ReleaseFile struct {
+	Name      string
+	Size      int64
+	SHA256Sum string
+}
+
+func newReleaseFile(f crt.File) ReleaseFile {
+	return ReleaseFile{Name: f.Name, Size: f.Size, SHA256Sum: f.SHA256Sum}
+}
+
+func (m *ReleaseManifest) Error() error {
+	if m.ErrorMessage == "" {
+		return nil
+	}
+	return errors.New(m.ErrorMessage)
+}
+
+func (m *ReleaseManifest) IsFromCache() bool { return false }
+
+// NewReleaseManifest returns a manifest of the artifacts described by builds,
+// which must all be successful builds of the same product version, revision,
+// and source, by the same tool and Go version. Builds for the same platform
+// and variant must agree on the zip and executable digests. If expected isn't empty, any
+// platforms in it without an artifact are listed as missing.
+//
+// The returned manifest's error is set if any platforms are missing or any
+// artifacts weren't reproduced.
+func NewReleaseManifest(builds []ReleaseBuild, expected []string) (*ReleaseManifest, error) {
+	if len(builds) == 0 {
+		return nil, errors.New("no build results")
+	}
+	for _, b := range builds {
+		if !b.Result.Successful {
+			return nil, fmt.Errorf("%s is a failed build", b.Name)
+		}
+	}
+	first := builds[0].Result.Config
+	m := &ReleaseManifest{
+		Product:    first.Product.Name,
+		Version:    first.Product.Version.Full,
+		Repository: first.Product.Repository,
+		Revision:   first.Product.Revision,
+		SourceHash: first.Product.SourceHash,
+		GoVersion:  first.Parameters.GoVersion,
+		Tool:       first.Tool,
+	}
+	if err := checkReleaseAgrees(builds); err != nil {
+		return nil, err
+	}
+	if err := m.addArtifacts(builds); err != nil {
+		return nil, err
+	}
+	if err := m.addMissingPlatforms(expected); err != nil {
+		return nil, err
+	}
+	var problems []string
+	if len(m.MissingPlatforms) != 0 {
+		problems = append(problems, "missing platforms: "+strings.Join(m.MissingPlatforms, ", "))
+	}
+	var notReproduced []string
+	for _, a := range m.Artifacts {
+		if a.Status == NotReproduced {
+			notReproduced = append(notReproduced, a.Platform)
+		}
+	}
+	if len(notReproduced) != 0 {
+		problems = append(problems, "not reproduced: "+strings.Join(notReproduced, ", "))
+	}
+	m.ErrorMessage = strings.Join(problems, "; ")
+	return m, nil
+}
+
+// checkReleaseAgrees returns an error listing every property that differs
+// between builds.
+func checkReleaseAgrees(builds []ReleaseBuild) error {
+	properties := []struct {
+		name  string
+		value func(Config) string
+	}{
+		{"product name", func(c Config) string { return c.Product.Name }},
+		{"version", func(c Config) string { return c.Product.Version.Full }},
+		{"revision", func(c Config) string { return c.Product.Revision }},
+		{"source hash", func(c Config) string { return c.Product.SourceHash }},
+		{"Go version", func(c Config) string { return c.Parameters.GoVersion }},
+		{"tool", func(c Config) string {
+			t := c.Tool
+			return fmt.Sprintf("%s %s (%s)", t.Name, t.Version, t.Revision)
+		}},
+	}
+	var problems []string
+	for _, p := range properties {
+		names := map[string][]string{}
+		var values []string
+		for _, b := range builds {
+			v := p.value(b.Result.Config)
+			if _, ok := names[v]; !ok {
+				values = append(values, v)
+			}
+			names[v] = append(names[v], b.Name)
+		}
+		if len(values) == 1 {
+			continue
+		}
+		var desc []string
+		for _, v := range values {
+			desc = append(desc, fmt.Sprintf("%q in %s", v, strings.Join(names[v], ", ")))
+		}
+		problems = append(problems, fmt.Sprintf("%s differs: %s", p.name, strings.Join(desc, "; ")))
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("build results are not from the same release: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// addArtifacts adds an artifact for each platform and variant in builds.
+func (m *ReleaseManifest) addArtifacts(builds []ReleaseBuild) error {
+	byPlatform := map[string][]ReleaseBuild{}
+	var platforms []string
+	for _, b := range builds {
+		p := b.Result.Config.Parameters.Platform()
+		if _, ok := byPlatform[p]; !ok {
+			platforms = append(platforms, p)
+		}
+		byPlatform[p] = append(byPlatform[p], b)
+	}
+	sort.Strings(platforms)
+	for _, p := range platforms {
+		a, err := newReleaseArtifact(p, byPlatform[p])
+		if err != nil {
+			return err
+		}
+		m.Artifacts = append(m.Artifacts, a)
+	}
+	return nil
+}
+
+// newReleaseArtifact returns the artifact for platform, described by builds.
+// It's reproduced if every verification result says so, and unverified if
+// there are none.
+func newReleaseArtifact(platform string, builds []ReleaseBuild) (ReleaseArtifact, error) {
+	first := builds[0]
+	a := ReleaseArtifact{
+		Platform:   platform,
+		Zip:        newReleaseFile(first.Result.Zip),
+		Executable: newReleaseFile(first.Result.Executable),
+		Status:     Unverified,
+	}
+	for _, b := range builds {
+		zip, exe := newReleaseFile(b.Result.Zip), newReleaseFile(b.Result.Executable)
+		if zip != a.Zip || exe != a.Executable {
+			return a, fmt.Errorf("%s results %s and %s describe different artifacts", platform, first.Name, b.Name)
+		}
+		a.Results = append(a.Results, b.Name)
+		vr := b.Verification
+		switch {
+		case vr == nil || a.Status == NotReproduced:
+		case vr.ReproducedCorrectly && vr.Error() == nil:
+			a.Status = Reproduced
+		default:
+			a.Status = NotReproduced
+			a.ErrorMessage = fmt.Sprintf("%s: %s", b.Name, vr.ErrorMessage)
+		}
+	}
+	return a, nil
+}
+
+// addMissingPlatforms records expected and any platforms in it that have
+// no artifact.
+func (m *ReleaseManifest) addMissingPlatforms(expected []string) error {
+	have := map[string]bool{}
+	for _, a := range m.Artifacts {
+		have[a.Platform] = true
+	}
+	for _, p := range expected {
+		os, arch, ok := strings.Cut(p, "/")
+		if !ok || os == "" || arch == "" || strings.Contains(arch, "/") {
+			return fmt.Errorf("expected platform %q must be in the form os/arch or os/arch_variant", p)
+		}
+		m.ExpectedPlatforms = append(m.ExpectedPlatforms, p)
+		if !have[p] {
+			m.MissingPlatforms = append(m.MissingPlatforms, p)
+		}
+	}
+	sort.Strings(m.ExpectedPlatforms)
+	sort.Strings(m.MissingPlatforms)
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+// releaseBuild returns a successful build of lockbox for os/arch, read from
+// a build result file.
+func releaseBuild(os, arch string) ReleaseBuild {
+	r := &Result{Config: standardConfig("/work"), Successful: true}
+	r.Config.Parameters.OS, r.Config.Parameters.Arch = os, arch
+	r.Config.Product.SourceHash = "5ca1ab1e"
+	r.Config.Tool = crt.Tool{Name: "actions-go-build", Version: "0.1.9", Revision: "f00d"}
+	zipName := "lockbox_1.2.3_" + os + "_" + arch + ".zip"
+	r.Zip = crt.File{Name: zipName, OriginalPath: "/work/out/" + zipName, Size: 10, SHA256Sum: "zip-" + os + arch}
+	r.Executable = crt.File{Name: "lockbox", OriginalPath: "/work/dist/lockbox", Size: 20, SHA256Sum: "exe-" + os + arch}
+	return ReleaseBuild{Name: os + "_" + arch + ".buildresult.json", Result: r}
+}
+
+// verified returns b as if read from a verification result.
+func verified(b ReleaseBuild, reproduced bool) ReleaseBuild {
+	b.Name = strings.Replace(b.Name, "buildresult", "verificationresult", 1)
+	b.Verification = &VerificationResult{Primary: b.Result, ReproducedCorrectly: reproduced}
+	if !reproduced {
+		b.Verification.ErrorMessage = "zip hashes differ"
+	}
+	return b
+}
+
+func TestNewReleaseManifest(t *testing.T) {
+	linux, darwin, windows := releaseBuild("linux", "amd64"), releaseBuild("darwin", "arm64"), releaseBuild("windows", "amd64")
+	builds := []ReleaseBuild{linux, verified(linux, true), darwin, verified(windows, false)}
+	got, err := NewReleaseManifest(builds, []string{"linux/amd64", "linux/arm64", "darwin/arm64", "windows/amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ReleaseManifest{
+		Product:    "lockbox",
+		Version:    "1.2.3",
+		Repository: "dadgarcorp/lockbox",
+		Revision:   "cabba9e",
+		SourceHash: "5ca1ab1e",
+		Tool:       crt.Tool{Name: "actions-go-build", Version: "0.1.9", Revision: "f00d"},
+		Artifacts: []ReleaseArtifact{
+			{
+				Platform:   "darwin/arm64",
+				Zip:        ReleaseFile{Name: "lockbox_1.2.3_darwin_arm64.zip", Size: 10, SHA256Sum: "zip-darwinarm64"},
+				Executable: ReleaseFile{Name: "lockbox", Size: 20, SHA256Sum: "exe-darwinarm64"},
+				Status:     Unverified,
+				Results:    []string{"darwin_arm64.buildresult.json"},
+			},
+			{
+				Platform:   "linux/amd64",
+				Zip:        ReleaseFile{Name: "lockbox_1.2.3_linux_amd64.zip", Size: 10, SHA256Sum: "zip-linuxamd64"},
+				Executable: ReleaseFile{Name: "lockbox", Size: 20, SHA256Sum: "exe-linuxamd64"},
+				Status:     Reproduced,
+				Results:    []string{"linux_amd64.buildresult.json", "linux_amd64.verificationresult.json"},
+			},
+			{
+				Platform:     "windows/amd64",
+				Zip:          ReleaseFile{Name: "lockbox_1.2.3_windows_amd64.zip", Size: 10, SHA256Sum: "zip-windowsamd64"},
+				Executable:   ReleaseFile{Name: "lockbox", Size: 20, SHA256Sum: "exe-windowsamd64"},
+				Status:       NotReproduced,
+				ErrorMessage: "windows_amd64.verificationresult.json: zip hashes differ",
+				Results:      []string{"windows_amd64.verificationresult.json"},
+			},
+		},
+		ExpectedPlatforms: []string{"darwin/arm64", "linux/amd64", "linux/arm64", "windows/amd64"},
+		MissingPlatforms:  []string{"linux/arm64"},
+		ErrorMessage:      "missing platforms: linux/arm64; not reproduced: windows/amd64",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNewReleaseManifest_complete(t *testing.T) {
+	builds := []ReleaseBuild{verified(releaseBuild("linux", "amd64"), true), releaseBuild("darwin", "arm64")}
+	got, err := NewReleaseManifest(builds, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Error(); err != nil {
+		t.Errorf("got error %q for a complete release", err)
+	}
+}
+
+func TestNewReleaseManifest_variants(t *testing.T) {
+	v3 := releaseBuild("linux", "amd64")
+	v3.Name = "linux_amd64_v3.buildresult.json"
+	v3.Result.Config.Parameters.GOAMD64 = "v3"
+	v3.Result.Zip.Name, v3.Result.Zip.SHA256Sum = "lockbox_1.2.3_linux_amd64_v3.zip", "zip-linuxamd64v3"
+	got, err := NewReleaseManifest([]ReleaseBuild{releaseBuild("linux", "amd64"), v3}, []string{"linux/amd64", "linux/amd64_v3", "linux/arm_v7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var platforms []string
+	for _, a := range got.Artifacts {
+		platforms = append(platforms, a.Platform+" "+a.Zip.Name)
+	}
+	want := []string{"linux/amd64 lockbox_1.2.3_linux_amd64.zip", "linux/amd64_v3 lockbox_1.2.3_linux_amd64_v3.zip"}
+	if diff := cmp.Diff(want, platforms); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"linux/arm_v7"}, got.MissingPlatforms); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNewReleaseManifest_err(t *testing.T) {
+	cases := []struct {
+		desc     string
+		modify   func(*ReleaseBuild)
+		expected []string
+		wantErr  string
+	}{
+		{
+			"different revision",
+			func(b *ReleaseBuild) { b.Result.Config.Product.Revision = "deadbeef" },
+			nil,
+			`revision differs: "cabba9e" in linux_amd64.buildresult.json; "deadbeef" in darwin_arm64.buildresult.json`,
+		},
+		{
+			"different source hash",
+			func(b *ReleaseBuild) { b.Result.Config.Product.SourceHash = "abc" },
+			nil,
+			"source hash differs",
+		},
+		{
+			"different Go version",
+			func(b *ReleaseBuild) { b.Result.Config.Parameters.GoVersion = "1.21.1" },
+			nil,
+			"Go version differs",
+		},
+		{
+			"different tool",
+			func(b *ReleaseBuild) { b.Result.Config.Tool.Version = "0.1.8" },
+			nil,
+			"tool differs",
+		},
+		{
+			"different version",
+			func(b *ReleaseBuild) { b.Result.Config.Product.Version.Full = "1.2.4" },
+			nil,
+			"version differs",
+		},
+		{
+			"conflicting artifacts",
+			func(b *ReleaseBuild) {
+				b.Result.Config.Parameters.OS, b.Result.Config.Parameters.Arch = "linux", "amd64"
+			},
+			nil,
+			"linux/amd64 results linux_amd64.buildresult.json and darwin_arm64.buildresult.json describe different artifacts",
+		},
+		{
+			"failed build",
+			func(b *ReleaseBuild) { b.Result.Successful = false },
+			nil,
+			"darwin_arm64.buildresult.json is a failed build",
+		},
+		{
+			"invalid expected platform",
+			func(*ReleaseBuild) {},
+			[]string{"linux"},
+			`expected platform "linux" must be in the form os/arch or os/arch_variant`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			other := releaseBuild("darwin", "arm64")
+			c.modify(&other)
+			_, err := NewReleaseManifest([]ReleaseBuild{releaseBuild("linux", "amd64"), other}, c.expected)
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("got error %v; want it to contain %q", err, c.wantErr)
+			}
+		})
+	}
+}
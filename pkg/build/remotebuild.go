@@ -4,16 +4,17 @@
 package build
 
 import (
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/hashicorp/actions-go-build/internal/download"
 	"github.com/hashicorp/actions-go-build/internal/unzipper"
 	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // RemoteBuild is a build where the source code is hosted remotely.
@@ -67,35 +68,29 @@ func (rb *RemoteBuild) Steps() []Step {
 		newStep(fmt.Sprintf("get %s", rb.sourceURL), func() error {
 			c := rb.Config()
 			sourceArchiveName := fmt.Sprintf("%s-%s.zip", c.Product.Name, c.Product.Revision)
-			sourceArchivePath = filepath.Join(sourceDLDir, sourceArchiveName)
-			destFile, err := os.Create(sourceArchivePath)
-			if err != nil {
-				return err
+			if rb.sourceArchives == nil {
+				sourceArchivePath = filepath.Join(sourceDLDir, sourceArchiveName)
+				return rb.download(sourceArchivePath)
 			}
-			var closeErr error
-			defer func() { closeErr = destFile.Close() }()
-			response, err := http.Get(rb.sourceURL)
-			if err != nil {
-				return err
+			sourceArchivePath = rb.Dirs().SharedSourceArchivePath(sourceArchiveName)
+			downloaded, err := rb.sourceArchives.fetch(sourceArchivePath, rb.download)
+			if err == nil && !downloaded {
+				rb.Log("Using shared source archive %s", sourceArchivePath)
 			}
-			if response.StatusCode != http.StatusOK {
-				return fmt.Errorf("%s", response.Status)
-			}
-			var bodyCloseErr error
-			defer func() { bodyCloseErr = response.Body.Close() }()
-			if _, err := io.Copy(destFile, response.Body); err != nil {
-				return err
-			}
-			if closeErr != nil {
-				return closeErr
-			}
-			return bodyCloseErr
+			return err
 		}),
 		newStep("extract source code to temporary directory", func() error {
-			// Extract the downloaded zip file directly in the same dir as the zip.
-			// These zips contain a directory that contains all the code, so we'll
-			// use that directory as the build root.
-			return unzipper.New(rb.Debug).Unzip(sourceArchivePath, sourceDLDir)
+			// Extract the downloaded zip file in the download dir (the zip may be
+			// elsewhere if it's shared). These zips contain a directory that
+			// contains all the code, so we'll use that directory as the build root.
+			_, span := startSpan(rb.Settings.context, "build.extract", attribute.String("build.archive", sourceArchivePath))
+			err := unzipper.New(rb.Debug).Unzip(sourceArchivePath, sourceDLDir)
+			endSpan(span, err)
+			var entryErr *unzipper.EntryError
+			if errors.As(err, &entryErr) {
+				rb.Loud("Refusing to extract %s from %s: %s", entryErr.Entry, rb.sourceURL, entryErr.Err)
+			}
+			return err
 		}),
 		newStep("move source code to build root", func() error {
 			c := rb.Config()
@@ -106,5 +101,32 @@ func (rb *RemoteBuild) Steps() []Step {
 		}),
 	}
 
+	if v := rb.activeVariance(); v != nil && v.clockOffset() != 0 {
+		pre = append(pre, newStep("waiting for the variance clock offset", func() error {
+			return sleep(rb.Settings.context, v.clockOffset())
+		}))
+	}
+
 	return append(pre, rb.core.Steps()...)
 }
+
+// download downloads the source archive to path.
+func (rb *RemoteBuild) download(path string) error {
+	ctx, span := startSpan(rb.Settings.context, "build.download", attribute.String("url.full", rb.sourceURL))
+	err := rb.downloader().ToFile(ctx, rb.sourceURL, path)
+	if fi, statErr := os.Stat(path); err == nil && statErr == nil {
+		span.SetAttributes(attribute.Int64("build.download.size", fi.Size()))
+	}
+	endSpan(span, err)
+	return err
+}
+
+// maxSourceArchiveSize is the largest source archive we are willing to download.
+const maxSourceArchiveSize = 2 << 30
+
+func (rb *RemoteBuild) downloader() *download.Downloader {
+	return download.New(
+		download.WithLogFunc(rb.Log),
+		download.WithMaxSize(maxSourceArchiveSize),
+	)
+}
@@ -23,13 +23,32 @@ type Inputs struct {
 // Note that the Config will be different for each of
 // them because it contains build-host-specific paths.
 type Result struct {
-	Config          Config
-	Env             []string
-	Meta            Meta
-	Zip             crt.File
-	Executable      crt.File
-	err             error
-	ErrorMessage    string `json:",omitempty"`
+	Config     Config
+	Env        []string
+	Meta       Meta
+	Zip        crt.File
+	Executable crt.File
+	// Steps records each step that ran, in order.
+	Steps []StepRecord `json:",omitempty"`
+	// FailedStep is the description of the step that failed, if any.
+	FailedStep string `json:",omitempty"`
+	// FailedStepIndex is the index in Steps of the step that failed, if any.
+	FailedStepIndex *int `json:",omitempty"`
+	// PathLeaks lists build-specific paths found in the executable or zip.
+	PathLeaks []PathLeak `json:",omitempty"`
+	// UndeclaredInputs lists source files the build read that aren't in the
+	// product revision. It's only set if inputs were audited.
+	UndeclaredInputs []UndeclaredInput `json:",omitempty"`
+	// Variance records the perturbations applied to a verification build, if any.
+	Variance *Variance `json:",omitempty"`
+	// Log is the build log, containing the output of the build's commands.
+	Log *crt.File `json:",omitempty"`
+	// Toolchain identifies the C toolchain, if cgo was explicitly enabled.
+	Toolchain    *Toolchain `json:",omitempty"`
+	err          error
+	ErrorMessage string `json:",omitempty"`
+	// ErrorKind is set if the build was cancelled or timed out.
+	ErrorKind       ErrorKind `json:",omitempty"`
 	Successful      bool
 	loadedFromCache bool
 }
@@ -53,6 +72,23 @@ type Meta struct {
 	Duration      string
 }
 
+// StepStatus is the outcome of a build step.
+type StepStatus string
+
+const (
+	StepOK     StepStatus = "ok"
+	StepFailed StepStatus = "failed"
+)
+
+// StepRecord captures when a single build step ran, and its outcome.
+type StepRecord struct {
+	Description   string
+	Start, Finish time.Time
+	Duration      string
+	Status        StepStatus
+	Error         string `json:",omitempty"`
+}
+
 // Result makes BuildResult a ResultSource which can be used by the verifier.
 func (br Result) Result() (Result, error) {
 	return br, nil
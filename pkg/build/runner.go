@@ -4,13 +4,16 @@
 package build
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/hashicorp/actions-go-build/pkg/crt"
 	"github.com/hashicorp/actions-go-build/pkg/digest"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Runner is responsible for executing and logging build steps and
@@ -45,22 +48,116 @@ type Step struct {
 }
 
 func (br *Runner) Run() Result {
+	br.setLogAttrs(func(a *logAttrs) { a.setBuild(br.build.Config()) })
 	br.Log("Beginning build, rooted at %q", br.build.Config().Paths.WorkDir)
+	prevCtx := br.context
+	baseCtx, span := startSpan(prevCtx, "build.Runner.Run", br.build.Config().spanAttrs()...)
+	buildCtx, cancel := withTimeout(baseCtx, br.timeout)
+	defer func() {
+		cancel()
+		br.setContext(prevCtx)
+	}()
+	recordStep := func(desc string, action StepFunc) {
+		br.recordStepWithin(baseCtx, buildCtx, desc, action)
+	}
+	log := newBuildLog(br.nowFunc)
+	br.setBuildLog(log)
+	defer br.setBuildLog(nil)
 	br.start()
 	for _, s := range br.build.Steps() {
-		if br.recordStep(s.desc, s.action); br.Failed() {
+		if recordStep(s.desc, s.action); br.Failed() {
 			break
 		}
 	}
 	if !br.Failed() {
-		br.recordStep("recording executable file details", func() error {
+		recordStep("recording executable file details", func() error {
 			return br.RecordBin(br.build.Config().Paths.BinPath)
 		})
-		br.recordStep("recording zip file details", func() error {
+		recordStep("recording zip file details", func() error {
 			return br.RecordZip(br.build.Config().Paths.ZipPath)
 		})
+		recordStep("checking for build-specific paths in artifacts", func() error {
+			return br.RecordPathLeaks(br.build.Config())
+		})
+		recordStep("recording C toolchain identity", br.RecordToolchain)
+		if br.auditInputs {
+			recordStep("checking for undeclared inputs", br.RecordUndeclaredInputs)
+		}
+	}
+	if c, ok := br.build.(cleaner); ok {
+		if err := c.cleanUp(); err != nil {
+			br.Loud("WARNING: cleaning up after build: %s", err)
+		}
+	}
+	br.recordLog(log)
+	result := br.Result()
+	span.SetAttributes(result.spanAttrs()...)
+	endSpan(span, result.Error())
+	return result
+}
+
+// setContext sets the context used by the runner and the build's commands.
+func (br *Runner) setContext(ctx context.Context) {
+	br.context = ctx
+	if cs, ok := br.build.(contextSetter); ok {
+		cs.setContext(ctx)
+	}
+}
+
+// setLogAttrs updates the log attrs of the runner and the build.
+func (br *Runner) setLogAttrs(update func(*logAttrs)) {
+	update(br.logAttrs)
+	if g, ok := br.build.(logAttrsGetter); ok && g.getLogAttrs() != br.logAttrs {
+		update(g.getLogAttrs())
+	}
+}
+
+// setBuildLog sets the log used by the runner and the build's commands.
+func (br *Runner) setBuildLog(log *buildLog) {
+	var w io.Writer
+	if log != nil {
+		w = log
+	}
+	br.buildLog = w
+	if ls, ok := br.build.(buildLogSetter); ok {
+		ls.setBuildLog(w)
 	}
-	return br.Result()
+}
+
+// recordLog saves the build log to the meta dir. Failing to save it doesn't
+// fail the build, because the log isn't part of the build's output.
+func (br *Runner) recordLog(log *buildLog) {
+	path := br.build.Config().Paths.LogPath()
+	f, err := log.save(path)
+	if err != nil {
+		br.Loud("WARNING: saving build log to %q: %s", path, err)
+		return
+	}
+	br.result.Log = &f
+	br.Debug("Build log saved to %q", path)
+}
+
+// recordStepWithin records a step run with a context derived from buildCtx
+// and limited by the step timeout. If the step is interrupted, or buildCtx
+// is already done, the interruption is recorded as the step's error.
+func (br *Runner) recordStepWithin(baseCtx, buildCtx context.Context, desc string, step StepFunc) {
+	stepCtx, cancel := withTimeout(buildCtx, br.stepTimeout)
+	defer cancel()
+	stepCtx, span := startSpan(stepCtx, "build.step", attribute.String("build.step", desc))
+	br.setContext(stepCtx)
+	defer func() { endSpan(span, br.result.err) }()
+	br.recordStep(desc, func() error {
+		if kind, err := br.interruption(baseCtx, buildCtx, stepCtx); err != nil {
+			br.result.ErrorKind = kind
+			return err
+		}
+		err := step()
+		if kind, ierr := br.interruption(baseCtx, buildCtx, stepCtx); err != nil && ierr != nil {
+			br.result.ErrorKind = kind
+			return ierr
+		}
+		return err
+	})
 }
 
 func (br *Runner) isFinished() bool {
@@ -71,6 +168,7 @@ func (br *Runner) finish() {
 	if !br.isFinished() {
 		br.result.Config = br.build.Config()
 		br.result.Env = br.build.Env()
+		br.result.Variance = br.activeVariance()
 		br.result.Meta.Finish = br.nowFunc()
 		br.result.Meta.Duration = br.result.Meta.Finish.Sub(br.result.Meta.Start).String()
 		br.result.Successful = br.result.err == nil
@@ -98,26 +196,84 @@ func (br *Runner) RecordZip(path string) error {
 	return err
 }
 
+// RecordToolchain records the identity of the C toolchain, if cgo is
+// explicitly enabled.
+func (br *Runner) RecordToolchain() error {
+	c := br.build.Config()
+	env := append(os.Environ(), br.build.Env()...)
+	var err error
+	br.result.Toolchain, err = identifyToolchain(br.context, c.Paths.WorkDir, env, c.Parameters)
+	return err
+}
+
+// RecordPathLeaks records any build-specific paths found in the artifacts.
+// Leaks are only an error if the strictPaths setting is on.
+func (br *Runner) RecordPathLeaks(c Config) error {
+	var extra []string
+	if v := br.activeVariance(); v != nil && v.Home != "" {
+		extra = append(extra, v.Home)
+	}
+	leaks, err := findPathLeaks(c, br.result.Executable.SHA256Sum, extra...)
+	if err != nil {
+		return err
+	}
+	br.result.PathLeaks = leaks
+	if len(leaks) == 0 {
+		return nil
+	}
+	if br.strictPaths {
+		return errPathLeaks(leaks)
+	}
+	for _, l := range leaks {
+		br.Loud("WARNING: build-specific path %s", l)
+	}
+	return nil
+}
+
 func (br *Runner) start() *Runner {
 	br.result.Meta.Start = br.nowFunc()
 	return br
 }
 
 func (br *Runner) recordStep(desc string, step func() error) error {
+	br.setLogAttrs(func(a *logAttrs) {
+		a.setBuild(br.build.Config())
+		a.setStep(desc)
+	})
+	defer br.setLogAttrs(func(a *logAttrs) { a.setStep("") })
 	br.Debug("%s: starting", desc)
+	br.mark("%s: starting", desc)
+	rec := StepRecord{Description: desc, Start: br.nowFunc()}
 	err := step()
+	rec.Finish = br.nowFunc()
+	rec.Duration = rec.Finish.Sub(rec.Start).String()
 	if err == nil {
+		rec.Status = StepOK
+		br.result.Steps = append(br.result.Steps, rec)
 		br.Log("%s: ok", desc)
+		br.mark("%s: ok", desc)
 		return nil
 	}
 	// Add the step description to the error.
 	err = fmt.Errorf("%s: %w", desc, err)
+	rec.Status, rec.Error = StepFailed, err.Error()
+	index := len(br.result.Steps)
+	br.result.Steps = append(br.result.Steps, rec)
+	br.result.FailedStep, br.result.FailedStepIndex = desc, &index
 	br.result.err = err
 	br.result.ErrorMessage = err.Error()
 	br.Log("%s: failed: %s", desc, err)
+	br.mark("%s: failed: %s", desc, err)
 	return err
 }
 
+// mark writes a step marker to the build log, if there is one.
+func (br *Runner) mark(format string, a ...any) {
+	if log, ok := br.buildLog.(*buildLog); ok {
+		log.mark(format, a...)
+	}
+}
+
 func getFileDetails(path string) (crt.File, error) {
 	f := crt.File{
 		Name:         filepath.Base(path),
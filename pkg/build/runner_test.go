@@ -4,11 +4,16 @@
 package build
 
 import (
+	"context"
+	"errors"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/actions-go-build/pkg/crt"
+	"github.com/hashicorp/actions-go-build/pkg/digest"
 	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
 	"github.com/hashicorp/composite-action-framework-go/pkg/git"
 	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
@@ -33,6 +38,17 @@ func TestRunner_Run_ok(t *testing.T) {
 	if err := result.Error(); err != nil {
 		t.Fatal(err)
 	}
+	if len(result.Steps) != len(b.Steps())+4 {
+		t.Errorf("got %d step records; want %d", len(result.Steps), len(b.Steps())+4)
+	}
+	for _, s := range result.Steps {
+		if s.Status != StepOK || s.Error != "" || s.Finish.Before(s.Start) {
+			t.Errorf("got step record %+v; want ok", s)
+		}
+	}
+	if result.FailedStep != "" || result.FailedStepIndex != nil {
+		t.Errorf("got failed step %q; want none", result.FailedStep)
+	}
 }
 
 func TestRunner_Run_err(t *testing.T) {
@@ -62,6 +78,157 @@ func TestRunner_Run_err(t *testing.T) {
 	if want != got {
 		t.Fatalf("got error %q; want %q", got, want)
 	}
+	if result.FailedStep != "running build instructions" || result.FailedStepIndex == nil {
+		t.Fatalf("got failed step %q at %v; want running build instructions", result.FailedStep, result.FailedStepIndex)
+	}
+	last := result.Steps[len(result.Steps)-1]
+	if *result.FailedStepIndex != len(result.Steps)-1 || last.Status != StepFailed || last.Error != want {
+		t.Errorf("got last step record %+v at index %d; want the failed step", last, *result.FailedStepIndex)
+	}
+}
+
+func TestRunner_Run_interrupted(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		desc     string
+		opts     []Option
+		wantKind ErrorKind
+		wantErr  error
+	}{
+		{"step timeout", []Option{WithStepTimeout(200 * time.Millisecond)}, ErrorKindTimeout, ErrTimeout},
+		{"build timeout", []Option{WithTimeout(200 * time.Millisecond)}, ErrorKindTimeout, ErrTimeout},
+		{"canceled", []Option{WithContext(ctx)}, ErrorKindCanceled, ErrCanceled},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := tmp.Dir(t)
+			cfg := standardConfig(dir)
+			// The background sleep keeps stdout open, so this only returns
+			// quickly if the whole process group is killed.
+			cfg.Parameters.Instructions = "sleep 30 & sleep 30"
+			opts := append(c.opts, WithGracePeriod(100*time.Millisecond))
+			testBuild, err := New("test-build", cfg, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := testBuild.(*core)
+			b.createTestProductRepo(t)
+			r, err := NewRunner(b, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			result := r.Run()
+			if took := time.Since(start); took > 10*time.Second {
+				t.Errorf("build took %s; want it interrupted quickly", took)
+			}
+			if result.ErrorKind != c.wantKind {
+				t.Errorf("got error kind %q; want %q", result.ErrorKind, c.wantKind)
+			}
+			if err := result.Error(); !errors.Is(err, c.wantErr) {
+				t.Errorf("got error %v; want %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunner_Run_log(t *testing.T) {
+	dir := tmp.Dir(t)
+	c := standardConfig(dir)
+	c.Parameters.Instructions = "echo out; echo -n err >&2; go build -o $BIN_PATH"
+	testBuild, err := New("test-build", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testBuild.(*core)
+	b.createTestProductRepo(t)
+	r, err := NewRunner(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now, _ := standardCommitTime()
+	r.nowFunc = func() time.Time { return now }
+
+	result := r.Run()
+	if err := result.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Log == nil {
+		t.Fatal("got no log in result")
+	}
+	if got, want := result.Log.OriginalPath, filepath.Join(dir, "meta", "build.log"); got != want {
+		t.Errorf("got log path %q; want %q", got, want)
+	}
+	data, err := os.ReadFile(result.Log.OriginalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"2022-07-04T11:33:33.000Z ==> running build instructions: starting",
+		"2022-07-04T11:33:33.000Z out",
+		"2022-07-04T11:33:33.000Z err",
+		"2022-07-04T11:33:33.000Z ==> running build instructions: ok",
+	}, "\n")
+	if !strings.Contains(string(data), want) {
+		t.Errorf("got log:\n%s\nwant it to contain:\n%s", data, want)
+	}
+	if sum, err := digest.FileSHA256Hex(result.Log.OriginalPath); err != nil || sum != result.Log.SHA256Sum {
+		t.Errorf("got log digest %q, %v; want %q", sum, err, result.Log.SHA256Sum)
+	}
+}
+
+func TestRunner_Run_pathLeaks(t *testing.T) {
+
+	cases := []struct {
+		desc    string
+		strict  bool
+		wantErr bool
+	}{
+		{"lenient", false, false},
+		{"strict", true, true},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := tmp.Dir(t)
+			// Standard config doesn't use -trimpath, so the work dir leaks.
+			testBuild, err := New("test-build", standardConfig(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := testBuild.(*core)
+			b.createTestProductRepo(t)
+			r, err := NewRunner(b, WithStrictPaths(c.strict))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := r.Run()
+			if len(result.PathLeaks) == 0 {
+				t.Fatalf("got no path leaks; want at least one")
+			}
+			if got := result.PathLeaks[0].Path; got != dir {
+				t.Errorf("got leaked path %q; want %q", got, dir)
+			}
+			// The zip only contains the executable, whose leaks are
+			// reported for the executable itself.
+			for _, l := range result.PathLeaks {
+				if l.Entry != "" {
+					t.Errorf("got leak %s reported again for the zip entry", l)
+				}
+			}
+			if gotErr := result.Error() != nil; gotErr != c.wantErr {
+				t.Errorf("got error %v; want error: %t", result.Error(), c.wantErr)
+			}
+		})
+	}
 }
 
 const mainDotGo = `
@@ -9,6 +9,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/actions-go-build/internal/log"
 )
@@ -31,7 +34,30 @@ type Settings struct {
 	// in the verification root directory).
 	isVerification bool
 	cleanOnly      bool
+	strictPaths    bool
+	variance       *Variance
+	sourceSubdir   string
 	logPrefix      string
+	// timeout and stepTimeout limit the whole build and each step,
+	// unless they're zero.
+	timeout     time.Duration
+	stepTimeout time.Duration
+	// gracePeriod is how long commands have to exit after being asked to,
+	// before they're killed.
+	gracePeriod time.Duration
+	// buildLog, if set, also receives the output of commands.
+	buildLog io.Writer
+	// logAttrs are added to structured log events.
+	logAttrs *logAttrs
+	// concurrent is set when the primary and verification builds run at
+	// the same time.
+	concurrent bool
+	// sourceStrategy is how local verification builds get the source.
+	sourceStrategy SourceStrategy
+	// auditInputs records the source files the go command reads.
+	auditInputs bool
+	// sourceArchives, if set, shares source downloads between remote builds.
+	sourceArchives *SourceArchives
 }
 
 // Option represents a function that configures Settings.
@@ -82,6 +108,53 @@ func AsPrimaryBuild() Option { return func(s *Settings) { s.isVerification = fal
 // WithCleanOnly causes the build to fail early if it's not based on a clean worktree.
 func WithCleanOnly(on bool) Option { return func(s *Settings) { s.cleanOnly = on } }
 
+// WithStrictPaths causes the build to fail if build-specific paths are found in its artifacts.
+func WithStrictPaths(on bool) Option { return func(s *Settings) { s.strictPaths = on } }
+
+// WithVariance sets the perturbations applied to verification builds.
+// It has no effect on primary builds. Pass nil for no variance.
+func WithVariance(v *Variance) Option { return func(s *Settings) { s.variance = v } }
+
+// WithSourceSubdir tells local verification builds that the build instructions
+// run in subdir, relative to the root being copied. This is used for products
+// that live in a subdirectory of a repository but depend on files outside it.
+func WithSourceSubdir(subdir string) Option { return func(s *Settings) { s.sourceSubdir = subdir } }
+
+// WithTimeout limits how long the whole build can run for. Zero means no limit.
+func WithTimeout(d time.Duration) Option { return func(s *Settings) { s.timeout = d } }
+
+// WithStepTimeout limits how long each build step can run for. Zero means no limit.
+func WithStepTimeout(d time.Duration) Option { return func(s *Settings) { s.stepTimeout = d } }
+
+// WithGracePeriod sets how long commands have to exit after the build is
+// cancelled or times out, before their whole process group is killed.
+func WithGracePeriod(d time.Duration) Option { return func(s *Settings) { s.gracePeriod = d } }
+
+// WithConcurrent says whether the primary and verification builds run at the
+// same time. If they do, each build uses its own Go build cache and prefixes
+// its commands' output with its log prefix, local verification builds don't
+// copy the primary build's output dirs, and verifiers get both results at once.
+func WithConcurrent(on bool) Option { return func(s *Settings) { s.concurrent = on } }
+
+// WithSourceArchives makes remote builds share source archive downloads
+// using sa, rather than each downloading their own.
+func WithSourceArchives(sa *SourceArchives) Option {
+	return func(s *Settings) { s.sourceArchives = sa }
+}
+
+// WithSourceStrategy sets how local verification builds get their copy of
+// the source. The default is SourceCopy.
+func WithSourceStrategy(ss SourceStrategy) Option {
+	return func(s *Settings) { s.sourceStrategy = ss }
+}
+
+// WithAuditInputs records the source files the go command reads while running the
+// build instructions, and flags any that are untracked or ignored by git.
+func WithAuditInputs(on bool) Option { return func(s *Settings) { s.auditInputs = on } }
+
+// DefaultGracePeriod is the grace period used unless WithGracePeriod is passed.
+const DefaultGracePeriod = 10 * time.Second
+
 func newSettings(options []Option) (Settings, error) {
 	s := &Settings{}
 	err := s.setOptions(options...)
@@ -103,6 +176,12 @@ func (s *Settings) setDefaults() (err error) {
 	if s.context == nil {
 		s.context = context.Background()
 	}
+	if s.gracePeriod == 0 {
+		s.gracePeriod = DefaultGracePeriod
+	}
+	if s.sourceStrategy == "" {
+		s.sourceStrategy = SourceCopy
+	}
 	if s.Debug == nil {
 		s.Debug = log.Debug
 	}
@@ -116,15 +195,44 @@ func (s *Settings) setDefaults() (err error) {
 	WithDebugfunc(s.Debug)(s)
 	WithLogfunc(s.Log)(s)
 	WithLoudfunc(s.Loud)(s)
+	s.logAttrs = &logAttrs{logger: s.logPrefix}
+	s.Debug = s.logAttrs.wrap(s.Debug)
+	s.Log = s.logAttrs.wrap(s.Log)
+	s.Loud = s.logAttrs.wrap(s.Loud)
 	if s.stdout == nil {
 		s.stdout = os.Stderr
 	}
 	if s.stderr == nil {
 		s.stderr = os.Stderr
 	}
+	if s.concurrent && s.logPrefix != "" {
+		s.stdout = newPrefixWriter(s.stdout, s.logPrefix)
+		s.stderr = newPrefixWriter(s.stderr, s.logPrefix)
+	}
 	return nil
 }
 
+// activeVariance returns the variance to apply, which is always nil for primary builds.
+func (s *Settings) activeVariance() *Variance {
+	if !s.isVerification {
+		return nil
+	}
+	return s.variance
+}
+
+// sourceRoot returns the root of the source tree given the work dir,
+// by removing sourceSubdir.
+func (s *Settings) sourceRoot(workDir string) string {
+	sub := filepath.Clean(s.sourceSubdir)
+	if sub == "." {
+		return workDir
+	}
+	for range strings.Split(sub, string(filepath.Separator)) {
+		workDir = filepath.Dir(workDir)
+	}
+	return workDir
+}
+
 func resolveBashPath(path string) (string, error) {
 	if path == "" {
 		path = "bash"
@@ -137,6 +245,10 @@ func (s *Settings) makeLogFunc(logFunc log.Func) log.Func {
 }
 
 func makePrefixedLocFunc(name string, logFunc log.Func) log.Func {
+	// Structured logs have the prefix in a separate field.
+	if log.IsStructured() {
+		return logFunc
+	}
 	if name != "" {
 		name = name + ": "
 	}
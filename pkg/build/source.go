@@ -0,0 +1,188 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SourceStrategy is how local verification builds get their copy of the source.
+type SourceStrategy string
+
+const (
+	// SourceCopy copies the whole primary build root, including untracked
+	// files, the .git dir, and any previous build outputs.
+	SourceCopy SourceStrategy = "copy"
+	// SourceGit checks out the product revision in a new git worktree, then
+	// copies the dirty files over it, so the verification build only sees
+	// the files covered by the source hash.
+	SourceGit SourceStrategy = "git"
+)
+
+// SourceStrategies returns all the source strategies.
+func SourceStrategies() []SourceStrategy { return []SourceStrategy{SourceCopy, SourceGit} }
+
+// ParseSourceStrategy parses a source strategy name.
+func ParseSourceStrategy(s string) (SourceStrategy, error) {
+	for _, ss := range SourceStrategies() {
+		if string(ss) == s {
+			return ss, nil
+		}
+	}
+	return "", fmt.Errorf("unknown source strategy %q; want %q or %q", s, SourceCopy, SourceGit)
+}
+
+// gitTopLevel returns the root of the git worktree containing dir.
+func gitTopLevel(dir string) (string, error) {
+	out, err := gitOutput(context.Background(), dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("finding git worktree root of %s: %w", dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitOutput runs git in dir and returns its output, or an error including
+// what it wrote to stderr.
+func gitOutput(ctx context.Context, dir string, stdin *strings.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// useRepoRoot makes lv copy the whole repository containing its primary
+// root, by moving the primary root up to the repository root and adding
+// the difference to the source subdir.
+func (lv *LocalVerification) useRepoRoot() error {
+	top, err := gitTopLevel(lv.primaryRoot)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(lv.primaryRoot)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(top, root)
+	if err != nil {
+		return err
+	}
+	lv.primaryRoot = top
+	lv.sourceSubdir = filepath.Join(rel, lv.sourceSubdir)
+	return nil
+}
+
+// gitSteps prepare the source in vPath using a git worktree.
+func (lv *LocalVerification) gitSteps(vPath string) []Step {
+	product := lv.Config().Product
+	return []Step{
+		newStep("ensuring new empty directory to run build in", func() error {
+			if err := forgetWorktree(vPath); err != nil {
+				return err
+			}
+			return os.RemoveAll(vPath)
+		}),
+		newStep(fmt.Sprintf("checking out revision %s in a new git worktree", product.Revision), func() error {
+			// Force lets the same path be used again if its dir was deleted
+			// without the worktree being forgotten.
+			return lv.runGit("worktree", "add", "--force", "--detach", vPath, product.Revision)
+		}),
+		newStep(fmt.Sprintf("copying %d dirty files to the worktree", len(product.DirtyFiles)), func() error {
+			return lv.copyDirtyFiles(vPath, product.DirtyFiles)
+		}),
+	}
+}
+
+// cleaner is implemented by builds that have something to clean up once
+// they've been run and their results recorded.
+type cleaner interface {
+	cleanUp() error
+}
+
+// cleanUp forgets the git worktree used for the build, if there is one, so
+// it isn't left registered in the primary repository.
+func (lv *LocalVerification) cleanUp() error {
+	if lv.sourceStrategy != SourceGit {
+		return nil
+	}
+	return forgetWorktree(lv.sourceRoot(lv.Config().Paths.WorkDir))
+}
+
+// forgetWorktree unregisters the git worktree at vPath, if there is one,
+// keeping its files so that the build outputs in it can still be used.
+// Unlike git worktree prune, it leaves other worktrees alone, and unlike
+// git worktree remove, it doesn't delete the files.
+func forgetWorktree(vPath string) error {
+	dotGit := filepath.Join(vPath, ".git")
+	link, err := os.ReadFile(dotGit)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(link)), "gitdir: ")
+	if !ok {
+		return fmt.Errorf("%s is not a git worktree link", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(vPath, gitDir)
+	}
+	if filepath.Base(filepath.Dir(gitDir)) != "worktrees" {
+		return fmt.Errorf("%s links to %s, which is not a git worktree dir", dotGit, gitDir)
+	}
+	if err := os.RemoveAll(gitDir); err != nil {
+		return err
+	}
+	return os.Remove(dotGit)
+}
+
+// runGit runs git in the primary root.
+func (lv *LocalVerification) runGit(args ...string) error {
+	defer lv.flushOutputs()
+	cmd := lv.newCommand("git", args...)
+	cmd.Dir = lv.primaryRoot
+	return cmd.Run()
+}
+
+// copyDirtyFiles copies files, relative to the repository root, from the
+// primary root to vPath, or deletes them from vPath if they've been deleted.
+func (lv *LocalVerification) copyDirtyFiles(vPath string, files []string) error {
+	if v := lv.activeVariance(); v != nil && v.ShuffledCopy {
+		files = append([]string(nil), files...)
+		rng := rand.New(rand.NewSource(v.Seed))
+		rng.Shuffle(len(files), func(i, j int) { files[i], files[j] = files[j], files[i] })
+	}
+	for _, f := range files {
+		src, dst := filepath.Join(lv.primaryRoot, f), filepath.Join(vPath, f)
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(src); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := copyEntry(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+func TestLocalVerification_Steps_git(t *testing.T) {
+	dir := tmp.Dir(t)
+	oldTempDirFunc := TempDirFunc
+	TempDirFunc = func() string { return filepath.Join(dir, "tmp") }
+	t.Cleanup(func() { TempDirFunc = oldTempDirFunc })
+
+	// Make a committed repo, then dirty it.
+	c := standardConfig(filepath.Join(dir, "work"))
+	primary, err := New("primary", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := primary.(*core)
+	b.createTestProductRepo(t)
+	out, err := exec.Command("git", "-C", c.Paths.WorkDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.writeTestFile(t, "main.go", mainDotGo+"// changed\n")
+	b.writeTestFile(t, "new.go", "package main\n")
+	b.writeTestFile(t, "untracked.txt", "not covered by the source hash")
+	must(t, os.Remove(filepath.Join(c.Paths.WorkDir, "go.mod")))
+
+	// A worktree of the user's, whose dir has been deleted, mustn't be pruned.
+	stale := filepath.Join(dir, "stale")
+	must(t, exec.Command("git", "-C", c.Paths.WorkDir, "worktree", "add", "--detach", stale).Run())
+	must(t, os.RemoveAll(stale))
+
+	c.Product.Revision = strings.TrimSpace(string(out))
+	c.Product.SourceHash = "dirty"
+	c.Product.DirtyFiles = []string{"go.mod", "main.go", "new.go"}
+	lv, err := NewLocalVerification(c.Paths.WorkDir, time.Now(), c, WithSourceStrategy(SourceGit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Run the steps up to, but not including, the build itself.
+	runSourceSteps := func() {
+		for _, s := range lv.Steps() {
+			must(t, s.action())
+			if strings.HasPrefix(s.desc, "waiting") {
+				break
+			}
+		}
+	}
+	runSourceSteps()
+
+	vDir := lv.Config().Paths.WorkDir
+	for name, want := range map[string]string{
+		"main.go": mainDotGo + "// changed\n",
+		"new.go":  "package main\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(vDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %q; want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"go.mod", "untracked.txt"} {
+		if _, err := os.Stat(filepath.Join(vDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s: got error %v; want not exist", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vDir, ".git")); err != nil {
+		t.Errorf("verification root is not a git worktree: %s", err)
+	}
+
+	// The same path can be used again, even if its dir was deleted without
+	// the worktree being forgotten.
+	vPath := lv.(*LocalVerification).sourceRoot(vDir)
+	must(t, os.RemoveAll(vPath))
+	runSourceSteps()
+
+	must(t, lv.(*LocalVerification).cleanUp())
+	if _, err := os.Stat(filepath.Join(vDir, "main.go")); err != nil {
+		t.Errorf("cleaning up deleted the verification root: %s", err)
+	}
+	out, err = exec.Command("git", "-C", c.Paths.WorkDir, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := string(out); strings.Contains(list, vPath) || !strings.Contains(list, stale) {
+		t.Errorf("got worktrees:\n%s\nwant %s but not %s", list, stale, vPath)
+	}
+}
+
+func TestParseSourceStrategy(t *testing.T) {
+	for _, ss := range SourceStrategies() {
+		got, err := ParseSourceStrategy(string(ss))
+		if err != nil || got != ss {
+			t.Errorf("ParseSourceStrategy(%q) = %q, %v", ss, got, err)
+		}
+	}
+	if _, err := ParseSourceStrategy("rsync"); err == nil {
+		t.Errorf("got nil error for unknown strategy")
+	}
+}
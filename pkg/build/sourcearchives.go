@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SourceArchives shares source archive downloads between remote builds of
+// the same source, e.g. the builds of every platform in a release, so each
+// archive is only downloaded once however many builds use it. It's safe
+// for concurrent use.
+type SourceArchives struct {
+	mu        sync.Mutex
+	downloads map[string]*sourceDownload
+}
+
+type sourceDownload struct {
+	once sync.Once
+	err  error
+}
+
+// NewSourceArchives returns an empty SourceArchives.
+func NewSourceArchives() *SourceArchives {
+	return &SourceArchives{downloads: map[string]*sourceDownload{}}
+}
+
+// fetch calls download to write the archive to path, unless it's already
+// been called for path, in which case it waits for that call to finish and
+// returns its error. Failed downloads are forgotten once they finish, so a
+// later fetch tries again. It returns true if it called download.
+func (sa *SourceArchives) fetch(path string, download func(path string) error) (bool, error) {
+	sa.mu.Lock()
+	d, ok := sa.downloads[path]
+	if !ok {
+		d = &sourceDownload{}
+		sa.downloads[path] = d
+	}
+	sa.mu.Unlock()
+	called := false
+	d.once.Do(func() {
+		called = true
+		// Remove any archive left by a previous run, which would otherwise
+		// be treated as a partial download.
+		if d.err = os.RemoveAll(path); d.err != nil {
+			return
+		}
+		if d.err = os.MkdirAll(filepath.Dir(path), 0755); d.err != nil {
+			return
+		}
+		d.err = download(path)
+	})
+	if d.err != nil {
+		sa.mu.Lock()
+		if sa.downloads[path] == d {
+			delete(sa.downloads, path)
+		}
+		sa.mu.Unlock()
+	}
+	return called, d.err
+}
@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSourceArchives_fetch(t *testing.T) {
+	sa := NewSourceArchives()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "source.zip")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A leftover archive from a previous run must be removed first.
+	if err := os.WriteFile(path, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var calls, downloaded int32
+	download := func(p string) error {
+		atomic.AddInt32(&calls, 1)
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("archive exists before download: %v", err)
+		}
+		return os.WriteFile(p, []byte("source"), 0644)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			called, err := sa.fetch(path, download)
+			if err != nil {
+				t.Error(err)
+			}
+			if called {
+				atomic.AddInt32(&downloaded, 1)
+			}
+			// Every caller must see the finished download.
+			if data, err := os.ReadFile(path); err != nil || string(data) != "source" {
+				t.Errorf("got %q, %v; want the downloaded archive", data, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 || downloaded != 1 {
+		t.Errorf("got %d downloads reported by %d callers; want 1", calls, downloaded)
+	}
+
+	other := filepath.Join(dir, "b", "source.zip")
+	wantErr := errors.New("download failed")
+	for i := 0; i < 2; i++ {
+		if _, err := sa.fetch(other, func(string) error { atomic.AddInt32(&calls, 1); return wantErr }); err != wantErr {
+			t.Errorf("got error %v; want %v", err, wantErr)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("got %d downloads; want 3, as failed downloads are retried", calls)
+	}
+	called, err := sa.fetch(other, download)
+	if err != nil || !called {
+		t.Errorf("got called %t, error %v; want the retry to download the archive", called, err)
+	}
+}
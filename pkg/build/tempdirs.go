@@ -76,10 +76,25 @@ func (d TempDirs) SourceDownloadDir() string {
 	return d.cacheDir("sourcearchive")
 }
 
+// SharedSourceArchivePath is where the source archive called name is kept
+// when source archives are shared. Unlike the other cache dirs, it only
+// depends on the repository and source hash, so builds of every platform
+// use the same one.
+func (d TempDirs) SharedSourceArchivePath(name string) string {
+	return filepath.Join(TempDirFunc(), d.tool.Name, d.tool.Version, d.tool.Revision,
+		"cache", "sourcearchive", d.product.Repository, d.product.SourceHash, name)
+}
+
 func (d TempDirs) BuildResultCacheDir(extension ...string) string {
 	return d.cacheDir("buildresult", extension...)
 }
 
+// GoCacheDir is the Go build cache used by builds that run concurrently,
+// so they don't share one with each other.
+func (d TempDirs) GoCacheDir() string {
+	return d.cacheDir("gocache")
+}
+
 func (d TempDirs) VerificationResultCachePath(configID, zipName string) string {
 	return d.cacheDir("verificationresult", configID, zipName+".json")
 }
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/pkg/digest"
+)
+
+// Toolchain identifies the C toolchain used by a cgo build.
+type Toolchain struct {
+	CC  Compiler
+	CXX Compiler
+}
+
+// Compiler identifies a C or C++ compiler by its version output.
+type Compiler struct {
+	// Command is the compiler command, e.g. "gcc" or "zig cc -target aarch64-linux-gnu".
+	Command string
+	// Version is the first line of the compiler's --version output.
+	Version string `json:",omitempty"`
+	// VersionHash is the SHA256 of the compiler's full --version output.
+	// It's empty if the compiler couldn't be run.
+	VersionHash string `json:",omitempty"`
+	// Error is set if the compiler couldn't be run.
+	Error string `json:",omitempty"`
+}
+
+// differences lists the ways t differs from other.
+func (t *Toolchain) differences(other *Toolchain) []Difference {
+	var a, b Toolchain
+	if t != nil {
+		a = *t
+	}
+	if other != nil {
+		b = *other
+	}
+	return differences(
+		diff("CC", a.CC.Command, b.CC.Command),
+		diff("CC version", a.CC.VersionHash, b.CC.VersionHash),
+		diff("CXX", a.CXX.Command, b.CXX.Command),
+		diff("CXX version", a.CXX.VersionHash, b.CXX.VersionHash),
+	)
+}
+
+// identifyToolchain runs the C and C++ compilers in dir using env, to record
+// their identity. It returns nil if cgo isn't explicitly enabled. Compilers
+// not set in p are the ones go would use, as reported by go env. It's only an
+// error if the C compiler can't be run, because the C++ one is often missing
+// and rarely needed.
+func identifyToolchain(ctx context.Context, dir string, env []string, p Parameters) (*Toolchain, error) {
+	if p.CGOEnabled != "1" {
+		return nil, nil
+	}
+	t := &Toolchain{}
+	for _, c := range []struct {
+		name     string
+		command  string
+		compiler *Compiler
+	}{{"CC", p.CC, &t.CC}, {"CXX", p.CXX, &t.CXX}} {
+		command := c.command
+		if command == "" {
+			out, err := runIn(ctx, dir, env, "go", "env", c.name)
+			if err != nil {
+				return nil, fmt.Errorf("getting default %s: %w", c.name, err)
+			}
+			command = strings.TrimSpace(string(out))
+		}
+		*c.compiler = identifyCompiler(ctx, dir, env, command)
+		if c.name == "CC" && c.compiler.Error != "" {
+			return nil, fmt.Errorf("running C compiler %q: %s", command, c.compiler.Error)
+		}
+	}
+	return t, nil
+}
+
+func identifyCompiler(ctx context.Context, dir string, env []string, command string) Compiler {
+	c := Compiler{Command: command}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		c.Error = "no compiler command"
+		return c
+	}
+	out, err := runIn(ctx, dir, env, args[0], append(args[1:], "--version")...)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	c.Version, _, _ = strings.Cut(strings.TrimSpace(string(out)), "\n")
+	c.VersionHash, err = digest.SHA256Hex(bytes.NewReader(out))
+	if err != nil {
+		c.Error = err.Error()
+	}
+	return c
+}
+
+func runIn(ctx context.Context, dir string, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	out, err := cmd.Output()
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) != 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(ee.Stderr))
+	}
+	return out, err
+}
+
+// cgoCFlags returns the CGO_CFLAGS for a build rooted at workDir, or an
+// empty string if cgo isn't explicitly enabled. The build root is mapped to
+// "." so it isn't embedded in debug info.
+func (bp Parameters) cgoCFlags(workDir string) string {
+	if bp.CGOEnabled != "1" {
+		return ""
+	}
+	flags := bp.CGOCFlags
+	if flags == "" {
+		flags = "-O2 -g"
+	}
+	if bp.Sysroot != "" {
+		flags += " --sysroot=" + bp.Sysroot
+	}
+	return flags + " -ffile-prefix-map=" + workDir + "=."
+}
+
+// cgoLDFlags returns the CGO_LDFLAGS needed for the sysroot, if any.
+func (bp Parameters) cgoLDFlags() string {
+	if bp.CGOEnabled != "1" || bp.Sysroot == "" {
+		return ""
+	}
+	return "-O2 -g --sysroot=" + bp.Sysroot
+}
+
+var (
+	zigArchs = map[string]string{"amd64": "x86_64", "arm64": "aarch64", "386": "x86", "arm": "arm", "riscv64": "riscv64"}
+	zigOSes  = map[string]string{"linux": "linux-gnu", "darwin": "macos", "windows": "windows-gnu", "freebsd": "freebsd"}
+)
+
+// zigTarget returns the zig target triple for goos and goarch.
+func zigTarget(goos, goarch string) (string, error) {
+	arch, ok := zigArchs[goarch]
+	if !ok {
+		return "", fmt.Errorf("zig target not known for architecture %q", goarch)
+	}
+	os, ok := zigOSes[goos]
+	if !ok {
+		return "", fmt.Errorf("zig target not known for OS %q", goos)
+	}
+	if goos == "linux" && goarch == "arm" {
+		os = "linux-gnueabihf"
+	}
+	return arch + "-" + os, nil
+}
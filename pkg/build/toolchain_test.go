@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/actions-go-build/pkg/crt"
+)
+
+func TestParameters_setCToolchain(t *testing.T) {
+
+	t.Setenv("CC_FOR_linux_arm64", "aarch64-linux-gnu-gcc")
+
+	cases := []struct {
+		desc         string
+		in           Parameters
+		want         Parameters
+		wantWarnings []string
+	}{
+		{
+			"cgo not enabled",
+			Parameters{OS: "linux", Arch: "arm64", CC: "gcc", Sysroot: "/sysroot"},
+			Parameters{OS: "linux", Arch: "arm64"},
+			[]string{"BUILD_CC", "BUILD_SYSROOT"},
+		},
+		{
+			"per-target env",
+			Parameters{OS: "linux", Arch: "arm64", CGOEnabled: "1", CXX: "g++"},
+			Parameters{OS: "linux", Arch: "arm64", CGOEnabled: "1", CC: "aarch64-linux-gnu-gcc", CXX: "g++"},
+			nil,
+		},
+		{
+			"zig",
+			Parameters{OS: "darwin", Arch: "amd64", CGOEnabled: "1", Zig: "zig"},
+			Parameters{OS: "darwin", Arch: "amd64", CGOEnabled: "1", Zig: "zig",
+				CC: "zig cc -target x86_64-macos", CXX: "zig c++ -target x86_64-macos"},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			o := crt.Origins{}
+			got, err := c.in.setCToolchain(o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Error(diff)
+			}
+			for _, name := range c.wantWarnings {
+				if o[name] == nil || len(o[name].Warnings) != 1 {
+					t.Errorf("%s: got origin %+v; want one warning", name, o[name])
+				}
+			}
+		})
+	}
+}
+
+func TestParameters_cgoFlags(t *testing.T) {
+	bp := Parameters{CGOEnabled: "1", Sysroot: "/sysroot"}
+	if got, want := bp.cgoCFlags("/work"), "-O2 -g --sysroot=/sysroot -ffile-prefix-map=/work=."; got != want {
+		t.Errorf("got CGO_CFLAGS %q; want %q", got, want)
+	}
+	if got, want := bp.cgoLDFlags(), "-O2 -g --sysroot=/sysroot"; got != want {
+		t.Errorf("got CGO_LDFLAGS %q; want %q", got, want)
+	}
+	bp.CGOEnabled = ""
+	if got := bp.cgoCFlags("/work"); got != "" {
+		t.Errorf("got CGO_CFLAGS %q without cgo; want empty", got)
+	}
+}
+
+func TestIdentifyToolchain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake compiler")
+	}
+	env := append(os.Environ(), "CC=", "CXX=")
+	dir := t.TempDir()
+
+	got, err := identifyToolchain(context.Background(), dir, env, Parameters{})
+	if err != nil || got != nil {
+		t.Fatalf("got %+v, %v without cgo; want nil, nil", got, err)
+	}
+
+	fakeCC := filepath.Join(dir, "fakecc")
+	if err := os.WriteFile(fakeCC, []byte("#!/bin/sh\necho \"fakecc $1 1.0\"\necho more\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	got, err = identifyToolchain(context.Background(), dir, env, Parameters{CGOEnabled: "1", CC: fakeCC + " -x", CXX: "no-such-compiler"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CC.Version != "fakecc -x 1.0" || got.CC.VersionHash == "" {
+		t.Errorf("got CC %+v; want fakecc version output", got.CC)
+	}
+	if got.CXX.Error == "" || got.CXX.VersionHash != "" {
+		t.Errorf("got CXX %+v; want an error", got.CXX)
+	}
+
+	_, err = identifyToolchain(context.Background(), dir, env, Parameters{CGOEnabled: "1", CC: "no-such-compiler"})
+	if err == nil || !strings.Contains(err.Error(), `running C compiler "no-such-compiler"`) {
+		t.Errorf("got error %v; want error running C compiler", err)
+	}
+}
+
+func TestVerifier_toolchainMismatch(t *testing.T) {
+	primary := Result{Toolchain: &Toolchain{CC: Compiler{Command: "gcc", VersionHash: "a"}}}
+	verification := Result{Toolchain: &Toolchain{CC: Compiler{Command: "gcc", VersionHash: "b"}}}
+	v, err := NewVerifier(primary, verification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = v.Verify()
+	want := `C toolchains are not identical: CC version: "a" != "b"`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %q", err, want)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a span using the global tracer provider, which does
+// nothing unless tracing has been set up.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer("github.com/hashicorp/actions-go-build/pkg/build").
+		Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, recording err if it's not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// spanAttrs describes the build configured by c.
+func (c Config) spanAttrs() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("product.name", c.Product.Name),
+		attribute.String("product.version", c.Product.Version.Full),
+		attribute.String("product.revision", c.Product.Revision),
+		attribute.String("build.os", c.Parameters.OS),
+		attribute.String("build.arch", c.Parameters.Arch),
+	}
+}
+
+// spanAttrs describes the outcome of a build.
+func (r Result) spanAttrs() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.Bool("build.successful", r.Successful)}
+	if r.FailedStep != "" {
+		attrs = append(attrs, attribute.String("build.failed_step", r.FailedStep))
+	}
+	if r.ErrorKind != "" {
+		attrs = append(attrs, attribute.String("build.error_kind", string(r.ErrorKind)))
+	}
+	return attrs
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records spans in memory,
+// for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return exporter
+}
+
+func spanAttr(s tracetest.SpanStub, key string) attribute.Value {
+	for _, a := range s.Attributes {
+		if string(a.Key) == key {
+			return a.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestManager_Result_spans(t *testing.T) {
+
+	cases := []struct {
+		desc      string
+		build     *mockBuild
+		wantCache string
+		wantSteps int
+	}{
+		{"cache hit", &mockBuild{cached: result("cached")}, "hit", 0},
+		{"cache miss", &mockBuild{fresh: result("fresh")}, "miss", 1},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			exporter := recordSpans(t)
+			runner, err := NewRunner(c.build)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := NewManager(runner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.Result(); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := exporter.GetSpans()
+			root := spans[len(spans)-1]
+			if root.Name != "build.Manager.Result" {
+				t.Fatalf("got last span %q; want build.Manager.Result", root.Name)
+			}
+			if got := spanAttr(root, "build.cache").AsString(); got != c.wantCache {
+				t.Errorf("got build.cache %q; want %q", got, c.wantCache)
+			}
+			var steps int
+			for _, s := range spans {
+				if s.SpanContext.TraceID() != root.SpanContext.TraceID() {
+					t.Errorf("span %q is in a different trace", s.Name)
+				}
+				if s.Name != "build.step" {
+					continue
+				}
+				steps++
+				if got := spanAttr(s, "build.step").AsString(); got != "fresh" {
+					t.Errorf("got step %q; want fresh", got)
+				}
+				if s.Status.Description != "fresh: an error" {
+					t.Errorf("got step status %q; want the step's error", s.Status.Description)
+				}
+			}
+			if steps != c.wantSteps {
+				t.Errorf("got %d step spans; want %d", steps, c.wantSteps)
+			}
+		})
+	}
+}
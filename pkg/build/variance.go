@@ -0,0 +1,361 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VarianceKind names one of the things that a variance build perturbs.
+type VarianceKind string
+
+const (
+	// VaryPathDepth nests the verification build root inside extra directories.
+	VaryPathDepth VarianceKind = "path"
+	// VaryUser changes USER, LOGNAME and HOME.
+	VaryUser VarianceKind = "user"
+	// VaryTZ changes the TZ env var.
+	VaryTZ VarianceKind = "tz"
+	// VaryLocale changes the LANG and LC_ALL env vars.
+	VaryLocale VarianceKind = "locale"
+	// VaryUmask changes the umask the build instructions run with.
+	VaryUmask VarianceKind = "umask"
+	// VaryFileOrder copies the source files in a shuffled order, so they are
+	// created in a different order on disk (local verification builds only).
+	VaryFileOrder VarianceKind = "order"
+	// VaryGOMAXPROCS changes the GOMAXPROCS env var.
+	VaryGOMAXPROCS VarianceKind = "gomaxprocs"
+	// VaryClock delays the start of the build by an extra offset.
+	VaryClock VarianceKind = "clock"
+)
+
+// VarianceKinds returns all the kinds of variance, in the order they are applied.
+func VarianceKinds() []VarianceKind {
+	return []VarianceKind{
+		VaryPathDepth, VaryUser, VaryTZ, VaryLocale,
+		VaryUmask, VaryFileOrder, VaryGOMAXPROCS, VaryClock,
+	}
+}
+
+// ParseVarianceKinds parses a comma-separated list of variance kinds.
+// The special value "all" means every kind.
+func ParseVarianceKinds(s string) ([]VarianceKind, error) {
+	if s == "all" {
+		return VarianceKinds(), nil
+	}
+	var kinds []VarianceKind
+	for _, k := range strings.Split(s, ",") {
+		kind := VarianceKind(strings.TrimSpace(k))
+		if !containsKind(VarianceKinds(), kind) {
+			return nil, fmt.Errorf("unknown variance kind %q; valid kinds are: all, %s", kind, kindList(VarianceKinds()))
+		}
+		if !containsKind(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+func containsKind(kinds []VarianceKind, k VarianceKind) bool {
+	for _, kind := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+func kindList(kinds []VarianceKind) string {
+	s := make([]string, len(kinds))
+	for i, k := range kinds {
+		s[i] = string(k)
+	}
+	return strings.Join(s, ", ")
+}
+
+// Variance records the perturbations applied to a verification build. These
+// are things that should not affect the artifacts produced, so if applying
+// them causes verification to fail, the build is not robustly reproducible.
+// Fields are left empty for kinds of variance that were not applied.
+type Variance struct {
+	// Seed determines all the values below, as well as the file order.
+	// Passing the same seed and kinds to NewVariance reproduces them.
+	Seed int64
+	// Kinds lists the kinds of variance applied.
+	Kinds []VarianceKind
+	// ExtraPathDepth is the number of extra directories the build root is nested in.
+	ExtraPathDepth int    `json:",omitempty"`
+	User           string `json:",omitempty"`
+	Home           string `json:",omitempty"`
+	TZ             string `json:",omitempty"`
+	Locale         string `json:",omitempty"`
+	// Umask is in octal, e.g. "0077".
+	Umask        string `json:",omitempty"`
+	ShuffledCopy bool   `json:",omitempty"`
+	GOMAXPROCS   int    `json:",omitempty"`
+	// ClockOffset is added to the verification build's start time.
+	ClockOffset string `json:",omitempty"`
+}
+
+// These lists of values are chosen to be unusual, in the hope that they
+// differ from whatever the primary build used.
+var (
+	varianceTimezones = []string{"Pacific/Chatham", "America/St_Johns", "Asia/Kathmandu", "Etc/GMT+12"}
+	varianceLocales   = []string{"tr_TR.UTF-8", "fr_CH.UTF-8", "ja_JP.UTF-8", "C.UTF-8"}
+	varianceUmasks    = []string{"0002", "0027", "0077"}
+)
+
+// NewVariance deterministically chooses a value for each kind of variance
+// using seed. If no kinds are passed, all kinds are applied.
+func NewVariance(seed int64, kinds ...VarianceKind) *Variance {
+	if len(kinds) == 0 {
+		kinds = VarianceKinds()
+	}
+	v := &Variance{Seed: seed}
+	rng := rand.New(rand.NewSource(seed))
+	// Consider every kind in a fixed order so the values chosen for each
+	// kind don't depend on which other kinds are enabled.
+	for _, k := range VarianceKinds() {
+		n := rng.Intn(1 << 16)
+		if !containsKind(kinds, k) {
+			continue
+		}
+		v.Kinds = append(v.Kinds, k)
+		switch k {
+		case VaryPathDepth:
+			v.ExtraPathDepth = 1 + n%4
+		case VaryUser:
+			v.User = fmt.Sprintf("variance%04d", n%10000)
+			v.Home = filepath.Join(TempDirFunc(), fmt.Sprintf("actions-go-build-variance-%d", seed), "home", v.User)
+		case VaryTZ:
+			v.TZ = varianceTimezones[n%len(varianceTimezones)]
+		case VaryLocale:
+			v.Locale = varianceLocales[n%len(varianceLocales)]
+		case VaryUmask:
+			v.Umask = varianceUmasks[n%len(varianceUmasks)]
+		case VaryFileOrder:
+			v.ShuffledCopy = true
+		case VaryGOMAXPROCS:
+			v.GOMAXPROCS = otherThan(runtime.NumCPU(), 1+n%(2*runtime.NumCPU()))
+		case VaryClock:
+			v.ClockOffset = (time.Duration(5+n%55) * time.Second).String()
+		}
+	}
+	return v
+}
+
+// otherThan returns n, unless it equals avoid, in which case it returns
+// some other positive number.
+func otherThan(avoid, n int) int {
+	if n != avoid {
+		return n
+	}
+	if avoid == 1 {
+		return 2
+	}
+	return 1
+}
+
+// String lists the perturbations applied.
+func (v *Variance) String() string {
+	if v == nil {
+		return "none"
+	}
+	var parts []string
+	add := func(k VarianceKind, val any) {
+		if containsKind(v.Kinds, k) {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, val))
+		}
+	}
+	add(VaryPathDepth, fmt.Sprintf("+%d", v.ExtraPathDepth))
+	add(VaryUser, v.User)
+	add(VaryTZ, v.TZ)
+	add(VaryLocale, v.Locale)
+	add(VaryUmask, v.Umask)
+	add(VaryFileOrder, "shuffled")
+	add(VaryGOMAXPROCS, v.GOMAXPROCS)
+	add(VaryClock, "+"+v.ClockOffset)
+	return fmt.Sprintf("seed=%d %s", v.Seed, strings.Join(parts, " "))
+}
+
+// root returns dir nested inside ExtraPathDepth more directories.
+func (v *Variance) root(dir string) string {
+	for i := 1; i <= v.ExtraPathDepth; i++ {
+		dir = filepath.Join(dir, "v"+strconv.Itoa(i))
+	}
+	return dir
+}
+
+// clockOffset returns the parsed ClockOffset, or zero if it's not set.
+func (v *Variance) clockOffset() time.Duration {
+	d, _ := time.ParseDuration(v.ClockOffset)
+	return d
+}
+
+// homeRoot is the directory Home is created in.
+func (v *Variance) homeRoot() string {
+	return filepath.Dir(filepath.Dir(v.Home))
+}
+
+// removeHome removes Home and everything in it, if it's set.
+func (v *Variance) removeHome() error {
+	if v.Home == "" {
+		return nil
+	}
+	return os.RemoveAll(v.homeRoot())
+}
+
+// env returns env vars to append to the build environment. When HOME is
+// changed, it's created empty, so the build doesn't see anything left by a
+// previous build with the same seed, and the Go build and module caches are
+// pinned to their usual locations so the build doesn't need to download
+// everything again. Call removeHome once the build has finished.
+func (v *Variance) env() ([]string, error) {
+	var env []string
+	if v.User != "" {
+		if err := v.removeHome(); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(v.Home, 0755); err != nil {
+			return nil, err
+		}
+		pinned, err := goCacheEnv()
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, pinned...)
+		env = append(env, "USER="+v.User, "LOGNAME="+v.User, "HOME="+v.Home)
+	}
+	if v.TZ != "" {
+		env = append(env, "TZ="+v.TZ)
+	}
+	if v.Locale != "" {
+		env = append(env, "LANG="+v.Locale, "LC_ALL="+v.Locale)
+	}
+	if v.GOMAXPROCS != 0 {
+		env = append(env, "GOMAXPROCS="+strconv.Itoa(v.GOMAXPROCS))
+	}
+	return env, nil
+}
+
+// goCacheEnv returns GOPATH, GOCACHE and GOMODCACHE set to the values
+// Go would use given the current environment.
+func goCacheEnv() ([]string, error) {
+	gopath := goPath()
+	modcache := os.Getenv("GOMODCACHE")
+	if modcache == "" && gopath != "" {
+		modcache = filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+	}
+	gocache := os.Getenv("GOCACHE")
+	if gocache == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		gocache = filepath.Join(dir, "go-build")
+	}
+	return []string{"GOPATH=" + gopath, "GOCACHE=" + gocache, "GOMODCACHE=" + modcache}, nil
+}
+
+// instructions prefixes instructions with the umask command if needed.
+func (v *Variance) instructions(instructions string) string {
+	if v.Umask == "" {
+		return instructions
+	}
+	return fmt.Sprintf("umask %s\n%s", v.Umask, instructions)
+}
+
+// shuffledCopy copies the directory tree at src to dst, creating all the
+// directories first, then the files and symlinks in a random order
+// determined by seed. Paths relative to src for which skip returns true
+// are not copied.
+func shuffledCopy(src, dst string, seed int64, skip func(rel string) bool) error {
+	var dirs, others []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if skip(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			dirs = append(dirs, rel)
+		} else {
+			others = append(others, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// WalkDir visits parents before children, but sort anyway to make that explicit.
+	sort.Strings(dirs)
+	for _, d := range dirs {
+		info, err := os.Stat(filepath.Join(src, d))
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Join(dst, d), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	for _, o := range others {
+		if err := copyEntry(filepath.Join(src, o), filepath.Join(dst, o)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyEntry(src, dst string) (err error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot copy %s: unsupported file mode %s", src, info.Mode())
+	}
+	s, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	d, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := d.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = io.Copy(d, s)
+	return err
+}
@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+func TestParseVarianceKinds(t *testing.T) {
+
+	cases := []struct {
+		in      string
+		want    []VarianceKind
+		wantErr bool
+	}{
+		{"all", VarianceKinds(), false},
+		{"tz", []VarianceKind{VaryTZ}, false},
+		{"umask, order,umask", []VarianceKind{VaryUmask, VaryFileOrder}, false},
+		{"tz,blah", nil, true},
+		{"", nil, true},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.in, func(t *testing.T) {
+			got, err := ParseVarianceKinds(c.in)
+			if gotErr := err != nil; gotErr != c.wantErr {
+				t.Fatalf("got error %v; want error: %t", err, c.wantErr)
+			}
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestNewVariance(t *testing.T) {
+	all := NewVariance(42)
+	if diff := cmp.Diff(all, NewVariance(42, VarianceKinds()...)); diff != "" {
+		t.Errorf("same seed gave different variance:\n%s", diff)
+	}
+
+	// Values chosen for one kind must not depend on which other kinds are on.
+	some := NewVariance(42, VaryTZ, VaryUmask)
+	want := &Variance{Seed: 42, Kinds: []VarianceKind{VaryTZ, VaryUmask}, TZ: all.TZ, Umask: all.Umask}
+	if diff := cmp.Diff(some, want); diff != "" {
+		t.Errorf(diff)
+	}
+}
+
+func TestRunner_Run_variance(t *testing.T) {
+	dir := tmp.Dir(t)
+	oldTempDirFunc := TempDirFunc
+	TempDirFunc = func() string { return filepath.Join(dir, "tmp") }
+	t.Cleanup(func() { TempDirFunc = oldTempDirFunc })
+
+	v := NewVariance(1, VaryUser, VaryTZ, VaryLocale, VaryUmask, VaryGOMAXPROCS)
+	c := standardConfig(filepath.Join(dir, "work"))
+	c.Parameters.Instructions += ` && test ! -e "$HOME/stale" && echo "$USER $HOME $TZ $LC_ALL $(umask) $GOMAXPROCS" > variance.txt`
+	// A previous build with the same seed left something in HOME.
+	must(t, os.MkdirAll(v.Home, 0755))
+	must(t, os.WriteFile(filepath.Join(v.Home, "stale"), nil, 0644))
+	opts := []Option{AsVerificationBuild(), WithVariance(v)}
+	testBuild, err := New("test-build", c, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := testBuild.(*core)
+	b.createTestProductRepo(t)
+	r, err := NewRunner(b, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := r.Run()
+	if err := result.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Variance != v {
+		t.Errorf("got variance %v recorded; want %v", result.Variance, v)
+	}
+
+	got, err := os.ReadFile(filepath.Join(c.Paths.WorkDir, "variance.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%s %s %s %s %s %d", v.User, v.Home, v.TZ, v.Locale, v.Umask, v.GOMAXPROCS)
+	if strings.TrimSpace(string(got)) != want {
+		t.Errorf("got build env %q; want %q", got, want)
+	}
+	if _, err := os.Stat(v.Home); !os.IsNotExist(err) {
+		t.Errorf("got variance home directory left behind: %v", err)
+	}
+}
+
+func TestShuffledCopy(t *testing.T) {
+	src, dst := tmp.Dir(t), filepath.Join(tmp.Dir(t), "copy")
+	files := map[string]string{
+		"a.go":         "package a",
+		"sub/b.go":     "package b",
+		"sub/deep/c.g": "c",
+		"run.sh":       "#!/bin/sh",
+	}
+	for name, contents := range files {
+		path := filepath.Join(src, name)
+		must(t, os.MkdirAll(filepath.Dir(path), 0755))
+		must(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	must(t, os.Chmod(filepath.Join(src, "run.sh"), 0755))
+	must(t, os.Symlink("a.go", filepath.Join(src, "link.go")))
+
+	must(t, shuffledCopy(src, dst, 7, func(string) bool { return false }))
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %q; want %q", name, got, want)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(dst, "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode() != 0755 {
+		t.Errorf("run.sh: got mode %s; want %s", fi.Mode(), os.FileMode(0755))
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link.go")); err != nil || target != "a.go" {
+		t.Errorf("link.go: got target %q (error %v); want %q", target, err, "a.go")
+	}
+}
@@ -4,10 +4,14 @@
 package build
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/actions-go-build/pkg/crt"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ResultSource interface {
@@ -37,24 +41,81 @@ func NewVerifier(primary, verification ResultSource, opts ...Option) (*Verifier,
 // when the result itself says that the reproduction didn't work.
 // You still need to query the result to find out if it was successful.
 func (v *Verifier) Verify() (*VerificationResult, error) {
+	ctx, span := startSpan(v.context, "build.Verifier.Verify")
+	result, err := v.verify(ctx)
+	if err == nil {
+		span.SetAttributes(result.Primary.Config.spanAttrs()...)
+		span.SetAttributes(
+			attribute.Bool("verify.reproduced", result.ReproducedCorrectly),
+			attribute.Bool("verify.dirty", result.Dirty),
+		)
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+func (v *Verifier) verify(ctx context.Context) (*VerificationResult, error) {
 	v.Debug("beginning verification")
-	pr, err := v.loadResult("primary", v.primary)
+	if v.concurrent {
+		return v.verifyConcurrently(ctx)
+	}
+	pr, err := v.loadResult(ctx, "primary", v.primary)
+	if err != nil {
+		return nil, err
+	}
+	v.checkPrimary(pr)
+
+	vr, err := v.loadResult(ctx, "verification", v.verification)
 	if err != nil {
 		return nil, err
 	}
+	return v.compare(pr, vr)
+}
 
+// verifyConcurrently gets both results at the same time. If getting either
+// one fails, the other is cancelled.
+func (v *Verifier) verifyConcurrently(ctx context.Context) (*VerificationResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		pr, vr   *Result
+		firstErr error
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+	load := func(name string, rs ResultSource, r **Result) {
+		defer wg.Done()
+		var err error
+		if *r, err = v.loadResult(ctx, name, rs); err != nil {
+			// The first error is the one that caused the other to be cancelled.
+			once.Do(func() { firstErr = err })
+			cancel()
+		}
+	}
+	wg.Add(2)
+	go load("primary", v.primary, &pr)
+	go load("verification", v.verification, &vr)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	v.checkPrimary(pr)
+	return v.compare(pr, vr)
+}
+
+// checkPrimary logs details of the primary build result.
+func (v *Verifier) checkPrimary(pr *Result) {
 	v.Debug("got primary build result: error: %v", pr.Error())
 
 	if pr.Config.Product.IsDirty() {
 		v.Loud("WARNING: Primary build is dirty: source hash (%s...) != revision (%s...)",
 			pr.Config.Product.SourceHash[:8], pr.Config.Product.Revision[:8])
 	}
+}
 
-	vr, err := v.loadResult("verification", v.verification)
-	if err != nil {
-		return nil, err
-	}
-
+// compare logs details of the verification build result and compares it
+// with the primary build result.
+func (v *Verifier) compare(pr, vr *Result) (*VerificationResult, error) {
 	v.Debug("got verification build result: error: %v", pr.Error())
 
 	if pr.Config.Product.IsDirty() {
@@ -65,8 +126,12 @@ func (v *Verifier) Verify() (*VerificationResult, error) {
 	return v.verificationResult(pr, vr)
 }
 
-func (v *Verifier) loadResult(name string, rs ResultSource) (*Result, error) {
+// loadResult gets a result from rs. If rs is a build, it runs in ctx.
+func (v *Verifier) loadResult(ctx context.Context, name string, rs ResultSource) (*Result, error) {
 	v.Debug("Getting %s build result", name)
+	if cs, ok := rs.(contextSetter); ok {
+		cs.setContext(ctx)
+	}
 	r, err := rs.Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get %s build result: %w", name, err)
@@ -86,6 +151,9 @@ func (v *Verifier) verificationResult(pr, vr *Result) (*VerificationResult, erro
 	if diff := cmp.Diff(pr.Config.Parameters, vr.Config.Parameters); diff != "" {
 		return nil, fmt.Errorf("build parameters are not identical: %s", diff)
 	}
+	if ds := pr.Toolchain.differences(vr.Toolchain); len(ds) != 0 {
+		return nil, fmt.Errorf("C toolchains are not identical: %s", formatDifferences(ds))
+	}
 
 	binHashes, binErr := v.fileHashes("executable", pr.Executable, vr.Executable)
 	zipHashes, zipErr := v.fileHashes("zip", pr.Zip, vr.Zip)
@@ -115,6 +183,14 @@ func (v *Verifier) verificationResult(pr, vr *Result) (*VerificationResult, erro
 	}, nil
 }
 
+func formatDifferences(ds []Difference) string {
+	out := make([]string, len(ds))
+	for i, d := range ds {
+		out[i] = fmt.Sprintf("%s: %q != %q", d.Name, d.Primary, d.Verification)
+	}
+	return strings.Join(out, "; ")
+}
+
 func (v *Verifier) fileHashes(desc string, pf, vf crt.File) (crt.FileHashes, error) {
 	v.Debug("Comparing primary and verification versions of %s file: %s", desc, pf.Name)
 	match := pf.SHA256Sum == vf.SHA256Sum
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package build
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitingSource is a ResultSource that signals when it's started, then
+// waits until release is closed or its context is done.
+type waitingSource struct {
+	ctx     context.Context
+	started chan<- string
+	release <-chan struct{}
+	name    string
+	err     error
+}
+
+func (s *waitingSource) setContext(ctx context.Context) { s.ctx = ctx }
+
+func (s *waitingSource) Result() (Result, error) {
+	s.started <- s.name
+	if s.err != nil {
+		return Result{}, s.err
+	}
+	select {
+	case <-s.release:
+		return Result{}, nil
+	case <-s.ctx.Done():
+		return Result{}, s.ctx.Err()
+	}
+}
+
+func TestVerifier_Verify_concurrent(t *testing.T) {
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	primary := &waitingSource{started: started, release: release, name: "primary"}
+	verification := &waitingSource{started: started, release: release, name: "verification"}
+	v, err := NewVerifier(primary, verification, WithConcurrent(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := v.Verify()
+		errs <- err
+	}()
+	// Both results must be requested before either is returned.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(10 * time.Second):
+			t.Fatal("results not requested concurrently")
+		}
+	}
+	close(release)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifier_Verify_concurrent_err(t *testing.T) {
+	started := make(chan string, 2)
+	primary := &waitingSource{started: started, name: "primary", err: errors.New("boom")}
+	verification := &waitingSource{started: started, name: "verification"}
+	v, err := NewVerifier(primary, verification, WithConcurrent(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The verification build waits until it's cancelled by the primary failing.
+	_, err = v.Verify()
+	want := "failed to get primary build result: boom"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %q", err, want)
+	}
+}
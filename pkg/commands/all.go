@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/actions-go-build/internal/config"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+)
+
+// allFlag is exposed by commands that can operate on every product declared
+// in a repository's products manifest, rather than a single build.
+type allFlag struct {
+	all bool
+}
+
+func (f *allFlag) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&f.all, "all", false, "run for every product and platform in the target's products manifest (.release/products.hcl or .json)")
+}
+
+// productBuild is a single product and platform from a products manifest.
+type productBuild struct {
+	// name identifies the product and platform, e.g. "lockbox linux/amd64".
+	name string
+	// root is the repository root.
+	root string
+	// subdir is the product dir relative to root.
+	subdir string
+	config build.Config
+}
+
+// productBuilds reads the products manifest from the target dir, which must be
+// the repository root, and returns the primary build config for each product.
+func (b *buildish) productBuilds() ([]productBuild, error) {
+	if b.buildFlags.config.step != nil {
+		return nil, fmt.Errorf("-workflow cannot be used with -all")
+	}
+	root, exists, err := b.resolvePath("dir", b.target, fs.DirExists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("-all requires the target to be the repository root directory, not %q", b.target)
+	}
+	configs, err := config.FromManifest(tool, root, b.buildFlags.config.options()...)
+	if err != nil {
+		return nil, err
+	}
+	b.dir = root
+	out := make([]productBuild, len(configs))
+	for i, c := range configs {
+		bc, err := c.PrimaryBuildConfig()
+		if err != nil {
+			return nil, err
+		}
+		subdir, err := filepath.Rel(root, c.Primary.BuildRoot)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = productBuild{
+			name:   fmt.Sprintf("%s %s/%s", c.Product.Name, c.Parameters.OS, c.Parameters.Arch),
+			root:   root,
+			subdir: subdir,
+			config: bc,
+		}
+	}
+	b.log("Found %d product builds in the products manifest in %s", len(out), root)
+	return out, nil
+}
+
+// newPrimaryManager returns a manager for the primary build of pb, run in place.
+func (pb productBuild) newPrimaryManager(flags buildFlags, what string) (*build.Manager, error) {
+	return flags.newPrimaryManager(pb.config, build.WithLogPrefix(pb.name+" "+what))
+}
+
+// newLocalVerificationManager returns a manager for a verification build of pb.
+// The whole repository is copied, so that paths shared between products are
+// available to the build.
+func (pb productBuild) newLocalVerificationManager(flags buildFlags, startAfter time.Time, what string) (*build.Manager, error) {
+	return flags.newLocalVerificationManager(pb.root, startAfter, pb.config,
+		build.WithLogPrefix(pb.name+" "+what),
+		build.AsVerificationBuild(),
+		build.WithSourceSubdir(pb.subdir),
+	)
+}
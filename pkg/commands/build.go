@@ -5,21 +5,49 @@ package commands
 
 import (
 	"flag"
+	"fmt"
+	"time"
 
+	"github.com/hashicorp/actions-go-build/pkg/build"
 	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
 )
 
 type buildOpts struct {
 	buildish
+	allFlag
+	variance varianceFlags
 }
 
 func (opts *buildOpts) Flags(fs *flag.FlagSet) {
 	opts.buildish.Flags(fs)
 	fs.BoolVar(&opts.buildFlags.forceVerification, "verification", false, "configure build as a verification build")
 	fs.BoolVar(&opts.buildFlags.requireClean, "clean", false, "fail unless worktree is clean")
+	opts.buildFlags.sourceFlag(fs)
+	opts.variance.Flags(fs)
+	opts.allFlag.Flags(fs)
+}
+
+func (opts *buildOpts) Init() error {
+	if err := opts.buildish.Init(); err != nil {
+		return err
+	}
+	var err error
+	if opts.buildFlags.variance, err = opts.variance.variance(); err != nil {
+		return err
+	}
+	if opts.buildFlags.variance != nil && !opts.buildFlags.forceVerification {
+		return fmt.Errorf("-variance only applies to verification builds; add -verification")
+	}
+	if opts.buildFlags.sourceStrategy != build.SourceCopy && !opts.buildFlags.forceVerification {
+		return fmt.Errorf("-source only applies to verification builds; add -verification")
+	}
+	return nil
 }
 
 var Build = cli.LeafCommand("build", "run a build", func(opts *buildOpts) error {
+	if opts.all {
+		return opts.buildAll()
+	}
 	build, err := opts.build("Running build")
 	if err != nil {
 		return err
@@ -30,3 +58,30 @@ var Build = cli.LeafCommand("build", "run a build", func(opts *buildOpts) error
 	}
 	return opts.output.result(opts.desc, result)
 })
+
+// buildAll runs the build for every product in the products manifest.
+func (opts *buildOpts) buildAll() error {
+	pbs, err := opts.productBuilds()
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(pbs))
+	results := make([]Result, len(pbs))
+	for i, pb := range pbs {
+		var m *build.Manager
+		if opts.buildFlags.forceVerification {
+			m, err = pb.newLocalVerificationManager(opts.buildFlags, time.Now(), "verification build")
+		} else {
+			m, err = pb.newPrimaryManager(opts.buildFlags, "build")
+		}
+		if err != nil {
+			return err
+		}
+		r, err := m.Result()
+		if err != nil {
+			return err
+		}
+		names[i], results[i] = pb.name, r
+	}
+	return opts.output.results("Build", names, results)
+}
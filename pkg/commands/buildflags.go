@@ -5,6 +5,7 @@ package commands
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"time"
 
@@ -22,6 +23,53 @@ type buildFlags struct {
 	// its own flags to populate these.
 	requireClean      bool
 	forceVerification bool
+
+	strictPaths bool
+	auditInputs bool
+
+	timeout, stepTimeout, gracePeriod time.Duration
+
+	config configFlags
+
+	// variance is set by commands that expose varianceFlags.
+	variance *build.Variance
+
+	// concurrent is set by commands that can run the primary and
+	// verification builds at the same time.
+	concurrent bool
+
+	// sourceStrategy is set by commands that expose sourceFlag.
+	sourceStrategy build.SourceStrategy
+}
+
+// varianceFlags are exposed by commands that run verification builds.
+type varianceFlags struct {
+	kinds string
+	seed  int64
+}
+
+func (flags *varianceFlags) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&flags.kinds, "variance", "", `perturb the verification build: comma-separated kinds, or "all"`)
+	fs.Int64Var(&flags.seed, "variance-seed", 0, "seed used to choose -variance values (default random)")
+}
+
+// variance returns nil if no variance was requested.
+func (flags *varianceFlags) variance() (*build.Variance, error) {
+	if flags.kinds == "" {
+		if flags.seed != 0 {
+			return nil, fmt.Errorf("-variance-seed requires -variance")
+		}
+		return nil, nil
+	}
+	kinds, err := build.ParseVarianceKinds(flags.kinds)
+	if err != nil {
+		return nil, err
+	}
+	seed := flags.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return build.NewVariance(seed, kinds...), nil
 }
 
 var wd = func() string {
@@ -33,7 +81,7 @@ var wd = func() string {
 }()
 
 func (flags *buildFlags) primaryBuildConfig() (build.Config, error) {
-	c, err := config.FromEnvironment(tool, wd)
+	c, err := config.FromEnvironment(tool, flags.config.workDir(), flags.config.options()...)
 	if err != nil {
 		return build.Config{}, err
 	}
@@ -41,13 +89,23 @@ func (flags *buildFlags) primaryBuildConfig() (build.Config, error) {
 }
 
 func (flags *buildFlags) localVerificationBuildConfig() (build.Config, error) {
-	c, err := config.FromEnvironment(tool, wd)
+	c, err := config.FromEnvironment(tool, flags.config.workDir(), flags.config.options()...)
 	if err != nil {
 		return build.Config{}, err
 	}
 	return c.VerificationBuildConfig()
 }
 
+// sourceFlag is exposed by commands that run local verification builds.
+func (flags *buildFlags) sourceFlag(fs *flag.FlagSet) {
+	flags.sourceStrategy = build.SourceCopy
+	fs.Func("source", `how local verification builds get the source: "copy" (default) copies the whole directory; "git" checks out the revision in a git worktree and copies dirty files`,
+		func(s string) (err error) {
+			flags.sourceStrategy, err = build.ParseSourceStrategy(s)
+			return err
+		})
+}
+
 func (flags *buildFlags) Flags(fs *flag.FlagSet) {
 	flags.logOpts.Flags(fs)
 	flags.ownFlags(fs)
@@ -55,6 +113,12 @@ func (flags *buildFlags) Flags(fs *flag.FlagSet) {
 
 func (flags *buildFlags) ownFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&flags.rebuild, "rebuild", false, "re-run the build even if cached")
+	fs.BoolVar(&flags.strictPaths, "strict-paths", false, "fail if build-specific paths are found in the artifacts")
+	fs.BoolVar(&flags.auditInputs, "audit-inputs", false, "record the source files compiled, and warn about any untracked or gitignored ones")
+	fs.DurationVar(&flags.timeout, "timeout", 0, "fail the build if it takes longer than this (default no limit)")
+	fs.DurationVar(&flags.stepTimeout, "step-timeout", 0, "fail the build if any step takes longer than this (default no limit)")
+	fs.DurationVar(&flags.gracePeriod, "grace-period", build.DefaultGracePeriod, "time to let interrupted build instructions exit before killing them")
+	flags.config.Flags(fs)
 }
 
 // A bunch of constructors for things we need configured according to flags.
@@ -123,5 +187,14 @@ func (flags *buildFlags) buildOptions(extraOpts ...build.Option) []build.Option
 	return append(flags.logOpts.buildOptions(extraOpts...),
 		build.WithForceRebuild(flags.rebuild),
 		build.WithCleanOnly(flags.requireClean),
+		build.WithStrictPaths(flags.strictPaths),
+		build.WithAuditInputs(flags.auditInputs),
+		build.WithVariance(flags.variance),
+		build.WithContext(ctx),
+		build.WithTimeout(flags.timeout),
+		build.WithStepTimeout(flags.stepTimeout),
+		build.WithGracePeriod(flags.gracePeriod),
+		build.WithConcurrent(flags.concurrent),
+		build.WithSourceStrategy(flags.sourceStrategy),
 	)
 }
@@ -4,10 +4,10 @@
 package commands
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path"
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/actions-go-build/internal/config"
+	"github.com/hashicorp/actions-go-build/internal/download"
 	"github.com/hashicorp/actions-go-build/pkg/build"
 	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
 	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
@@ -76,6 +77,10 @@ func (b *buildish) Args(args *cli.ArgList) {
 func (b *buildish) Init() error {
 	b.buildFlags.logOpts = b.logOpts
 	b.output.logOpts = b.logOpts
+	if err := b.buildFlags.config.Init(); err != nil {
+		return err
+	}
+	b.target = b.buildFlags.config.dir(b.target)
 	return nil
 }
 
@@ -148,19 +153,24 @@ func (b *buildish) getBuildFunc(why string, extraOpts ...build.Option) (buildFun
 	return build, err
 }
 
-// localFileConfigSource returns a buildFunc which derives build config from a JSON blob retrieved via HTTPS.
+// maxConfigSize is the largest build config, result, or verification result we will download.
+const maxConfigSize = 64 << 20
+
+// urlConfigSource returns a buildFunc which derives build config from a JSON blob retrieved via HTTPS.
 func (b *buildish) urlConfigSource(maybeURL string, extraOpts ...build.Option) (buildFunc, bool, error) {
 	u, err := url.Parse(maybeURL)
 	if err != nil {
 		b.debug("not a URL: %s: %s", maybeURL, err)
+		return nil, false, err
 	}
 	if u.Scheme != "https" {
 		return nil, false, fmt.Errorf("URLs must use https scheme")
 	}
 	return b.configSourceFromReadCloser(maybeURL, func() (io.ReadCloser, error) {
-		resp, err := http.Get(maybeURL)
-		return resp.Body, err
-	}, extraOpts...), true, err
+		d := download.New(download.WithLogFunc(b.logFunc()), download.WithMaxSize(maxConfigSize))
+		data, err := d.Bytes(ctx, maybeURL)
+		return io.NopCloser(bytes.NewReader(data)), err
+	}, extraOpts...), true, nil
 }
 
 // localFileConfigSource returns a buildFunc which derives build config from a JSON blob stored in a local file.
@@ -176,7 +186,7 @@ func (b *buildish) localFileConfigSource(maybeFile string, extraOpts ...build.Op
 func (b *buildish) localDirConfigSource(maybeDir string, extraOpts ...build.Option) (buildFunc, bool, error) {
 	absDir, exists, err := b.resolvePath("dir", maybeDir, fs.DirExists)
 	return func() (*build.Manager, error) {
-		c, err := config.FromEnvironment(tool, absDir)
+		c, err := config.FromEnvironment(tool, absDir, b.buildFlags.config.options()...)
 		if err != nil {
 			return nil, err
 		}
@@ -235,9 +245,8 @@ func (b *buildish) configSourceFromReadCloser(location string, rcFunc func() (io
 		if err != nil {
 			return nil, err
 		}
-		var closeErr error
-		defer func() { closeErr = rc.Close() }()
 		c, err := b.readConfig(rc)
+		closeErr := rc.Close()
 		if err != nil {
 			return nil, fmt.Errorf("unable to read build config from %q: %w", location, err)
 		}
@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/hashicorp/actions-go-build/internal/bundle"
+	"github.com/hashicorp/actions-go-build/internal/download"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+	"github.com/hashicorp/composite-action-framework-go/pkg/json"
+)
+
+// maxBundleSize is the largest release archive we will download.
+const maxBundleSize = 4 << 30
+
+// bundleLocation returns target if it's a bundle of published results, that
+// is an https URL of an archive, a local archive, or a directory that isn't
+// a Go module and has result files at its top level. Otherwise it returns "".
+func bundleLocation(target string) (string, error) {
+	if u, err := url.Parse(target); err == nil && u.Scheme == "https" {
+		if bundle.IsArchive(u.Path) {
+			return target, nil
+		}
+		return "", nil
+	}
+	if bundle.IsArchive(target) {
+		if exists, err := fs.FileExists(target); !exists || err != nil {
+			return "", err
+		}
+		return target, nil
+	}
+	if exists, err := fs.DirExists(target); !exists || err != nil {
+		return "", err
+	}
+	if isModule, err := fs.FileExists(filepath.Join(target, "go.mod")); isModule || err != nil {
+		return "", err
+	}
+	if hasResults, err := bundle.HasResults(target); !hasResults || err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// runBundleVerifications runs a remote verification build for every result
+// in the bundle, compares it with the result, and checks the published zip
+// the result describes.
+func (v *verifyish) runBundleVerifications() ([]string, []*build.VerificationResult, error) {
+	location, cleanup, err := v.fetchBundle()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+	b, err := bundle.Read(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(b.Results) == 0 {
+		return nil, nil, fmt.Errorf("no *%s or *%s files found in %s",
+			bundle.BuildResultSuffix, bundle.VerificationResultSuffix, v.bundle)
+	}
+	v.log("Found %d results and %d zips in %s", len(b.Results), len(b.Zips), v.bundle)
+	names := make([]string, len(b.Results))
+	results := make([]*build.VerificationResult, len(b.Results))
+	for i, rf := range b.Results {
+		r, err := readCleanResult(rf.Path, rf.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		vm, err := v.buildFlags.newRemoteVerificationManager(r.Config,
+			build.WithLogPrefix(rf.Path+" verification build"), build.AsVerificationBuild())
+		if err != nil {
+			return nil, nil, err
+		}
+		verifier, err := v.buildFlags.newVerifier(r, vm, build.WithLogPrefix(rf.Path))
+		if err != nil {
+			return nil, nil, err
+		}
+		if results[i], err = verifier.Verify(); err != nil {
+			return nil, nil, err
+		}
+		checkPublishedZip(results[i], b, rf.Path, r.Zip.Name, r.Zip.SHA256Sum)
+		names[i] = rf.Path
+	}
+	return names, results, nil
+}
+
+// fetchBundle returns the local path of the bundle, downloading it first
+// if it's a URL, and a func that removes any download.
+func (v *verifyish) fetchBundle() (string, func(), error) {
+	u, err := url.Parse(v.bundle)
+	if err != nil || u.Scheme != "https" {
+		return v.bundle, func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "actions-go-build.bundle")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			v.debug("Removing %s: %s", dir, err)
+		}
+	}
+	dest := filepath.Join(dir, path.Base(u.Path))
+	v.log("Downloading %s", v.bundle)
+	d := download.New(download.WithLogFunc(v.logFunc()), download.WithMaxSize(maxBundleSize))
+	if err := d.ToFile(ctx, v.bundle, dest); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dest, cleanup, nil
+}
+
+// readResult reads data, from the file called name, as a build result, or a
+// verification result in which case its primary build result is returned.
+func readResult(name string, data []byte) (*build.Result, error) {
+	r, _, err := readResultOrVerification(name, data)
+	return r, err
+}
+
+// readResultOrVerification is like readResult, but also returns the
+// verification result if data is one.
+func readResultOrVerification(name string, data []byte) (*build.Result, *build.VerificationResult, error) {
+	r, err := json.ReadBytes[build.Result](data)
+	if err == nil {
+		return &r, nil, nil
+	}
+	vr, vrErr := json.ReadBytes[build.VerificationResult](data)
+	if vrErr != nil || vr.Primary == nil {
+		return nil, nil, fmt.Errorf("%s is not a build result or verification result: %w", name, err)
+	}
+	return vr.Primary, &vr, nil
+}
+
+// readCleanResult is like readResult, but fails if the result is dirty, as
+// only clean builds can be reproduced remotely.
+func readCleanResult(name string, data []byte) (*build.Result, error) {
+	r, err := readResult(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if r.Config.Product.IsDirty() {
+		return nil, fmt.Errorf("%s: unable to run a remote build based on a dirty build result", name)
+	}
+	return r, nil
+}
+
+// checkPublishedZip fails vr unless b contains the zip called name, which
+// belongs to the result at resultPath, and its digest is sha256Sum.
+func checkPublishedZip(vr *build.VerificationResult, b *bundle.Bundle, resultPath, name, sha256Sum string) {
+	z, err := b.FindZip(resultPath, name)
+	if err == nil && z.SHA256Sum != sha256Sum {
+		err = fmt.Errorf("published zip %s has SHA256 %s; the build result has %s", z.Path, z.SHA256Sum, sha256Sum)
+	}
+	if err == nil {
+		return
+	}
+	vr.ReproducedCorrectly = false
+	if vr.ErrorMessage != "" {
+		vr.ErrorMessage += "; "
+	}
+	vr.ErrorMessage += err.Error()
+}
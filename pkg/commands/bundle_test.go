@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/actions-go-build/internal/bundle"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+)
+
+func TestBundleLocation(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	release := filepath.Dir(write("release/example.zip.buildresult.json"))
+	module := filepath.Dir(write("module/example.zip.buildresult.json"))
+	write("module/go.mod")
+	artifact := write("artifact.tgz")
+	for target, want := range map[string]string{
+		release:                                 release,
+		artifact:                                artifact,
+		"https://example.com/dist/artifact.zip": "https://example.com/dist/artifact.zip",
+		"https://example.com/example.buildresult": "",
+		module:                              "",
+		dir:                                 "",
+		filepath.Join(dir, "missing.zip"):   "",
+		"testdata/example.buildresult.json": "",
+	} {
+		got, err := bundleLocation(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("bundleLocation(%q) = %q; want %q", target, got, want)
+		}
+	}
+}
+
+func TestReadResult(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.buildresult.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vr := []byte(`{"Primary":` + string(data) + `,"Verification":null}`)
+	for _, d := range [][]byte{data, vr} {
+		r, err := readResult("result.json", d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "actions-go-build_0.1.4_darwin_arm64.zip"; r.Zip.Name != want {
+			t.Errorf("got zip name %q; want %q", r.Zip.Name, want)
+		}
+	}
+	for _, d := range []string{`{"Primary":null}`, `{"Foo":1}`, `[]`} {
+		if _, err := readResult("result.json", []byte(d)); err == nil {
+			t.Errorf("got nil error reading %s", d)
+		}
+	}
+}
+
+func TestCheckPublishedZip(t *testing.T) {
+	b := &bundle.Bundle{Zips: []bundle.Zip{{Path: "dist/example.zip", SHA256Sum: "abc"}}}
+	for _, c := range []struct {
+		desc, name, sum, errMessage, wantErr string
+		ok                                   bool
+	}{
+		{"match", "example.zip", "abc", "", "", true},
+		{"mismatch", "example.zip", "def", "", "published zip dist/example.zip has SHA256 abc; the build result has def", false},
+		{"missing", "other.zip", "abc", "", "zip not found: other.zip", false},
+		{"already failed", "other.zip", "abc", "hashes differ", "hashes differ; zip not found: other.zip", false},
+	} {
+		t.Run(c.desc, func(t *testing.T) {
+			vr := &build.VerificationResult{ReproducedCorrectly: c.errMessage == "", ErrorMessage: c.errMessage}
+			checkPublishedZip(vr, b, "dist/example.zip.buildresult.json", c.name, c.sum)
+			if vr.ReproducedCorrectly != c.ok || vr.ErrorMessage != c.wantErr {
+				t.Errorf("got %t %q; want %t %q", vr.ReproducedCorrectly, vr.ErrorMessage, c.ok, c.wantErr)
+			}
+		})
+	}
+}
@@ -12,25 +12,63 @@ import (
 )
 
 type configOpts struct {
-	github bool
+	configFlags
+	github  bool
+	explain bool
+	json    bool
 }
 
 func (c *configOpts) Flags(fs *flag.FlagSet) {
+	c.configFlags.Flags(fs)
 	fs.BoolVar(&c.github, "github", false, "export config to github env")
+	fs.BoolVar(&c.explain, "explain", false, "show where each value came from, and any warnings")
+	fs.BoolVar(&c.json, "json", false, "print the -explain output as json")
+}
+
+func (c *configOpts) Init() error {
+	if err := c.configFlags.Init(); err != nil {
+		return err
+	}
+	if c.json && !c.explain {
+		return fmt.Errorf("-json requires -explain")
+	}
+	if c.explain && c.github {
+		return fmt.Errorf("-explain cannot be used with -github")
+	}
+	return nil
 }
 
 var Config = cli.LeafCommand("config", "print config and export to GITHUB_ENV if set", func(opts *configOpts) error {
-	cfg, err := config.FromEnvironment(tool, wd)
+	cfg, err := config.FromEnvironment(tool, opts.workDir(), opts.options()...)
 	if err != nil {
 		return err
 	}
 	if opts.github {
 		return cfg.ExportToGitHubEnv()
 	}
+	if opts.explain {
+		return explainConfig(cfg, opts.json)
+	}
 	return dumpConfig(cfg)
 }).WithHelp(`
 Print the current configuration, determined by the environment and repository context.
 
+Values are also read from a config file, .release/build.hcl or .release/build.yaml
+(or the file passed to -config-file), and from -set flags. Flags take precedence over
+the environment, which takes precedence over the file. Each value read from one of these
+is followed by a comment saying where it came from.
+
+Use -workflow and -workflow-step to read values from the inputs of an actions-go-build
+step in a GitHub workflow file instead of the environment, to reproduce a CI build.
+Expressions in the inputs are evaluated using values set by -matrix flags.
+
+If VERSION_POLICY is set to "release", the product version is validated first, and any
+violations are reported before anything is printed or exported.
+
+Use the -explain flag to print a table showing each value, where it came from (e.g. a
+flag, the environment, a config file, the repository, or a default), and any warnings
+about it. Add -json to get the same information as JSON.
+
 Use the -github flag to export the full configuration to GITHUB_ENV. This is used by the
 action to gather configuration from all the inputs as well as the repository context, and
 to store that config so that subsequent steps can use it.
@@ -42,7 +80,30 @@ func dumpConfig(c config.Config) error {
 		return err
 	}
 	for _, v := range vars {
+		if source, ok := c.Sources[v.Name]; ok {
+			fmt.Fprintf(stdout, "%s=%s # %s\n", v.Name, v.Value, source)
+			continue
+		}
 		fmt.Fprintf(stdout, "%s=%s\n", v.Name, v.Value)
 	}
 	return nil
 }
+
+func explainConfig(c config.Config, asJSON bool) error {
+	explanations, err := c.Explain()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return dumpJSON(stdout, explanations)
+	}
+	var rows []string
+	rows = append(rows, "NAME\tSOURCE\tVALUE")
+	for _, e := range explanations {
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%q", e.Name, e.Source, e.Value))
+		for _, w := range e.Warnings {
+			rows = append(rows, fmt.Sprintf("\tWARNING: %s\t", w))
+		}
+	}
+	return tabWrite(&printer{w: stdout}, rows, func(row string) string { return row })
+}
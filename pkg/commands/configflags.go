@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/internal/config"
+	"github.com/hashicorp/actions-go-build/internal/workflow"
+)
+
+// configFlags are exposed by commands that read config from a local directory.
+// Values set using -set take precedence over inputs read from a workflow, which
+// take precedence over the environment, which takes precedence over the config file.
+type configFlags struct {
+	file   string
+	values setFlag
+
+	workflow     string
+	workflowStep string
+	matrix       setFlag
+	// step is read from workflow by Init.
+	step *workflow.Step
+}
+
+func (flags *configFlags) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&flags.file, "config-file", "", "read config from this HCL or YAML file (default .release/build.{hcl,yaml,yml} if present)")
+	fs.Var(&flags.values, "set", "set a config value, e.g. -set OS=darwin (may be repeated)")
+	fs.StringVar(&flags.workflow, "workflow", "", "read config from the inputs of an actions-go-build step in this GitHub workflow file")
+	fs.StringVar(&flags.workflowStep, "workflow-step", "", "the workflow job id, optionally followed by /<step id or name>")
+	fs.Var(&flags.matrix, "matrix", "set a matrix value used by the workflow step, e.g. -matrix os=darwin (may be repeated)")
+}
+
+// Init reads the workflow step, if -workflow is set.
+func (flags *configFlags) Init() error {
+	if flags.workflow == "" {
+		if flags.workflowStep != "" || len(flags.matrix) != 0 {
+			return fmt.Errorf("-workflow-step and -matrix require -workflow")
+		}
+		return nil
+	}
+	if flags.workflowStep == "" {
+		return fmt.Errorf("-workflow requires -workflow-step")
+	}
+	s, err := workflow.Read(flags.workflow, flags.workflowStep, flags.matrix)
+	if err != nil {
+		return err
+	}
+	flags.step = &s
+	return nil
+}
+
+// dir returns the directory to read config from: the work_dir of the workflow
+// step if there is one and target is the default target, otherwise target.
+func (flags *configFlags) dir(target string) string {
+	if flags.step == nil || target != defaultTarget {
+		return target
+	}
+	return flags.step.WorkDir()
+}
+
+// workDir returns the work_dir of the workflow step if there is one,
+// otherwise the current directory.
+func (flags *configFlags) workDir() string {
+	if flags.step == nil {
+		return wd
+	}
+	return flags.step.WorkDir()
+}
+
+func (flags *configFlags) options() []config.Option {
+	var opts []config.Option
+	if flags.file != "" {
+		opts = append(opts, config.WithFile(flags.file))
+	}
+	if len(flags.values) != 0 {
+		opts = append(opts, config.WithFlags(flags.values))
+	}
+	if flags.step != nil {
+		opts = append(opts, config.WithWorkflow(filepath.ToSlash(flags.workflow), flags.step.Env()))
+	}
+	return opts
+}
+
+// setFlag collects NAME=value pairs.
+type setFlag map[string]string
+
+func (s *setFlag) String() string { return "" }
+
+func (s *setFlag) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("%q must be in the form NAME=value", v)
+	}
+	if *s == nil {
+		*s = setFlag{}
+	}
+	(*s)[name] = value
+	return nil
+}
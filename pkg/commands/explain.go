@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
+	"github.com/hashicorp/composite-action-framework-go/pkg/json"
+)
+
+type explainOpts struct {
+	logOpts
+	buildFlags buildFlags
+	output     output
+	bisect     bool
+
+	// primaryFile is either a build result or a verification result.
+	primaryFile string
+	// verificationFile is a build result, and must be empty if primaryFile
+	// is a verification result.
+	verificationFile string
+}
+
+func (opts *explainOpts) Flags(fs *flag.FlagSet) {
+	cli.FlagFuncsAll(fs, opts.logOpts.Flags, opts.buildFlags.ownFlags, opts.output.ownFlags)
+	fs.BoolVar(&opts.bisect, "bisect", false, "rebuild toggling one input difference at a time to find the culprit")
+}
+
+func (opts *explainOpts) Args(args *cli.ArgList) {
+	args.Required(&opts.primaryFile, "result")
+	args.Optional(&opts.verificationFile, "verification-result", "")
+}
+
+func (opts *explainOpts) Init() error {
+	opts.buildFlags.logOpts = opts.logOpts
+	opts.output.logOpts = opts.logOpts
+	return nil
+}
+
+var Explain = cli.LeafCommand("explain", "explain why two builds differ", func(opts *explainOpts) error {
+	primary, verification, err := opts.results()
+	if err != nil {
+		return err
+	}
+	e, err := build.Explain(primary, verification)
+	if err != nil {
+		return err
+	}
+	if opts.bisect {
+		if err := e.Bisect(primary, verification, opts.runBisectBuild); err != nil {
+			return err
+		}
+	}
+	if opts.output.json {
+		return dumpJSON(os.Stdout, e)
+	}
+	p := printer{w: os.Stdout, printTitles: true, prefix: "    "}
+	return p.explanation(e, opts.bisect)
+}).WithHelp(`
+Explain the differences between a primary and verification build.
+
+Pass either a single verification result file, or a primary and verification
+build result file. The output lists the invariant build environment variables,
+inputs, Go versions, and tool versions that differ, as well as any
+build-specific paths found inside either executable (if they still exist on
+this machine).
+
+Use the -bisect flag to re-run the primary build once for each differing input,
+with only that input changed, in order to find the input responsible for the
+difference. This requires the primary build to be clean.
+`)
+
+func (opts *explainOpts) results() (primary, verification build.Result, err error) {
+	if opts.verificationFile != "" {
+		if primary, err = json.ReadFile[build.Result](opts.primaryFile); err != nil {
+			return
+		}
+		verification, err = json.ReadFile[build.Result](opts.verificationFile)
+		return
+	}
+	vr, err := json.ReadFile[build.VerificationResult](opts.primaryFile)
+	if err != nil {
+		return
+	}
+	if vr.Primary == nil || vr.Verification == nil {
+		err = fmt.Errorf("%s is not a verification result; pass two build result files instead", opts.primaryFile)
+		return
+	}
+	return *vr.Primary, *vr.Verification, nil
+}
+
+func (opts *explainOpts) runBisectBuild(c build.Config) (build.Result, error) {
+	m, err := opts.buildFlags.newRemoteVerificationManager(c, build.WithLogPrefix("bisect"))
+	if err != nil {
+		return build.Result{}, err
+	}
+	return m.Result()
+}
+
+func (p *printer) explanation(e build.Explanation, bisected bool) error {
+	status := "not reproduced"
+	if e.Reproduced {
+		status = "reproduced"
+	}
+	if _, err := fmt.Fprintf(p.w, "Zip file %s.\n", status); err != nil {
+		return err
+	}
+	for _, s := range []struct {
+		title string
+		diffs []build.Difference
+	}{
+		{"Invariant Environment Differences", e.Env},
+		{"Input Differences", e.Inputs},
+		{"Go Version Differences", e.GoVersion},
+		{"C Toolchain Differences", e.Toolchain},
+		{"Tool Differences", e.Tool},
+	} {
+		if err := p.differences(s.title, s.diffs); err != nil {
+			return err
+		}
+	}
+	if err := p.title("Path Leaks"); err != nil {
+		return err
+	}
+	if len(e.PathLeaks) == 0 {
+		if err := p.line("none found"); err != nil {
+			return err
+		}
+	}
+	for _, l := range e.PathLeaks {
+		if err := p.line("%s build: %s", l.Build, l); err != nil {
+			return err
+		}
+	}
+	if !bisected {
+		return nil
+	}
+	if err := p.title("Culprits"); err != nil {
+		return err
+	}
+	if len(e.Culprits) == 0 {
+		return p.line("no single input difference explains the mismatch")
+	}
+	return p.line("%s", strings.Join(e.Culprits, "\n"+p.prefix))
+}
+
+func (p *printer) differences(title string, diffs []build.Difference) error {
+	if err := p.title(title); err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return p.line("none")
+	}
+	return tabWrite(p, diffs, func(d build.Difference) string {
+		return fmt.Sprintf("%s%s\tprimary=%q\tverification=%q", p.prefix, d.Name, d.Primary, d.Verification)
+	})
+}
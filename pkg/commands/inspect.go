@@ -4,11 +4,13 @@
 package commands
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/actions-go-build/pkg/digest"
 	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
 	"github.com/hashicorp/composite-action-framework-go/pkg/json"
 )
@@ -23,6 +25,7 @@ type inspectOpts struct {
 	buildEnvDesc bool
 	zipInfo      bool
 	worktree     bool
+	logs         bool
 }
 
 func (opts *inspectOpts) Flags(fs *flag.FlagSet) {
@@ -35,10 +38,11 @@ func (opts *inspectOpts) Flags(fs *flag.FlagSet) {
 	fs.BoolVar(&opts.buildEnvDesc, "describe-build-env", false, "describe the build environment")
 	fs.BoolVar(&opts.zipInfo, "zip-info", false, "just print the zip details")
 	fs.BoolVar(&opts.worktree, "worktree", false, "print worktree status (clean/dirty)")
+	fs.BoolVar(&opts.logs, "logs", false, "print the log of the cached build")
 }
 
 func (opts *inspectOpts) HideFlags() []string {
-	return append(opts.logOpts.HideFlags(), "clean", "rebuild", "json")
+	return append(opts.logOpts.HideFlags(), "clean", "rebuild", "strict-paths", "json")
 }
 
 var Inspect = cli.LeafCommand("inspect", "inspect things", func(opts *inspectOpts) error {
@@ -77,6 +81,10 @@ var Inspect = cli.LeafCommand("inspect", "inspect things", func(opts *inspectOpt
 		return p.worktreeStatus()
 	}
 
+	if opts.logs {
+		return printLogs(bm)
+	}
+
 	return p.printAll()
 })
 
@@ -137,3 +145,31 @@ func (p *printer) worktreeStatus() error {
 	}
 	return nil
 }
+
+// printLogs prints the log of the cached build managed by bm, after checking
+// it hasn't changed since the build.
+func printLogs(bm *build.Manager) error {
+	r, cached, err := bm.ResultFromCache()
+	if err != nil {
+		return err
+	}
+	if !cached {
+		return fmt.Errorf("no cached build result; run the build first")
+	}
+	if r.Log == nil {
+		return fmt.Errorf("cached build result has no log")
+	}
+	data, err := os.ReadFile(r.Log.OriginalPath)
+	if err != nil {
+		return fmt.Errorf("reading build log: %w", err)
+	}
+	got, err := digest.SHA256Hex(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if got != r.Log.SHA256Sum {
+		return fmt.Errorf("build log %s has changed since the build: got SHA256 %s; want %s", r.Log.OriginalPath, got, r.Log.SHA256Sum)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
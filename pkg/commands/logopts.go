@@ -18,10 +18,11 @@ func (opts *logOpts) Flags(fs *flag.FlagSet) {
 	fs.BoolVar(&opts.debugFlag, "debug", false, "debug logging")
 	fs.BoolVar(&opts.verboseFlag, "v", false, "verbose logging")
 	fs.BoolVar(&opts.quietFlag, "q", false, "quiet logging")
+	fs.Func("log-format", `log format: "text" (default) or "json"`, log.SetFormat)
 }
 
 func (opts *logOpts) HideFlags() []string {
-	return []string{"debug", "v", "q"}
+	return []string{"debug", "v", "q", "log-format"}
 }
 
 func (opts *logOpts) newVerifier(primary, verification build.ResultSource) (*build.Verifier, error) {
@@ -34,7 +35,7 @@ func (opts *logOpts) loud(f string, a ...any)  { opts.loudFunc()(f, a...) }
 
 func (opts *logOpts) debugFunc() log.Func {
 	if opts.debugFlag {
-		return log.Info
+		return log.At(log.LevelDebug)
 	}
 	if opts.quietFlag {
 		return log.Discard
@@ -44,7 +45,7 @@ func (opts *logOpts) debugFunc() log.Func {
 
 func (opts *logOpts) logFunc() log.Func {
 	if opts.debugFlag || opts.verboseFlag {
-		return log.Info
+		return log.At(log.LevelVerbose)
 	}
 	if opts.quietFlag {
 		return log.Discard
@@ -0,0 +1,238 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/actions-go-build/internal/bundle"
+	"github.com/hashicorp/actions-go-build/internal/manifest"
+	"github.com/hashicorp/actions-go-build/internal/signing"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+	"github.com/hashicorp/composite-action-framework-go/pkg/json"
+)
+
+type manifestOpts struct {
+	logOpts
+	output           output
+	product          string
+	version          string
+	platforms        string
+	productsManifest string
+	outFile          string
+	signKey          string
+
+	// targets are result files, or directories or archives containing them.
+	targets []string
+}
+
+func (opts *manifestOpts) Flags(fs *flag.FlagSet) {
+	cli.FlagFuncsAll(fs, opts.logOpts.Flags, opts.output.ownFlags)
+	fs.StringVar(&opts.product, "product", "", "only include results for this product name")
+	fs.StringVar(&opts.version, "version", "", "only include results for this product version")
+	fs.StringVar(&opts.platforms, "platforms", "", "comma-separated os/arch platforms, optionally with a variant such as linux/amd64_v3, the release should have")
+	fs.StringVar(&opts.productsManifest, "products-manifest", "", "read the platforms the release should have from this products manifest")
+	fs.StringVar(&opts.outFile, "o", "", "write the manifest to this file (default <product>_<version>_manifest.json)")
+	fs.StringVar(&opts.signKey, "sign-key", "", "sign the manifest with this PEM-encoded Ed25519 private key")
+}
+
+func (opts *manifestOpts) Args(args *cli.ArgList) {
+	args.RequiredVariadic(&opts.targets, "results", 1)
+}
+
+func (opts *manifestOpts) Init() error {
+	opts.output.logOpts = opts.logOpts
+	if opts.platforms != "" && opts.productsManifest != "" {
+		return fmt.Errorf("-platforms cannot be used with -products-manifest")
+	}
+	return nil
+}
+
+var Manifest = cli.LeafCommand("manifest", "write a manifest of every artifact in a release", func(opts *manifestOpts) error {
+	builds, err := opts.readBuilds()
+	if err != nil {
+		return err
+	}
+	if builds, err = opts.selectRelease(builds); err != nil {
+		return err
+	}
+	product := builds[0].Result.Config.Product
+	expected, err := opts.expectedPlatforms(product.Name)
+	if err != nil {
+		return err
+	}
+	m, err := build.NewReleaseManifest(builds, expected)
+	if err != nil {
+		return err
+	}
+	if err := opts.write(m); err != nil {
+		return err
+	}
+	for _, a := range m.Artifacts {
+		opts.loud("%s %s %s", a.Platform, a.Zip.Name, a.Status)
+	}
+	return opts.output.result("Release manifest", m)
+}).WithHelp(`
+Write a manifest of every artifact in a release, that is every platform built
+for a single product version.
+
+Pass build result and verification result files, or directories, zips, or
+tarballs containing them. If they're for more than one product version, use
+-product and -version to choose one. Every result must be from the same
+revision and source, built by the same version of this tool and Go version,
+and results for the same platform must agree on the zip and executable
+digests. Platforms are os/arch, followed by the variant from the default zip
+name if there is one, e.g. linux/amd64_v3, so each variant is listed
+separately.
+
+The manifest lists the zip and executable for each platform, with their
+digests, and whether they were reproduced: "reproduced" or "not reproduced"
+if there is a verification result for the platform, otherwise "unverified".
+Use -platforms or -products-manifest to declare the platforms the release
+should have, and any without a result are listed as missing. The command fails
+if any platforms are missing or not reproduced, after writing the manifest.
+
+Use -sign-key to sign the manifest. Its public key is recorded in the manifest,
+and the base64-encoded signature of the manifest file is written alongside it,
+with a .sig suffix.
+`)
+
+// readBuilds reads the builds in or at each target.
+func (opts *manifestOpts) readBuilds() ([]build.ReleaseBuild, error) {
+	var builds []build.ReleaseBuild
+	for _, target := range opts.targets {
+		isDir, err := fs.DirExists(target)
+		if err != nil {
+			return nil, err
+		}
+		if !isDir && !bundle.IsArchive(target) {
+			data, err := os.ReadFile(target)
+			if err != nil {
+				return nil, err
+			}
+			b, err := readReleaseBuild(target, data)
+			if err != nil {
+				return nil, err
+			}
+			builds = append(builds, b)
+			continue
+		}
+		bun, err := bundle.Read(target)
+		if err != nil {
+			return nil, err
+		}
+		if len(bun.Results) == 0 {
+			return nil, fmt.Errorf("no *%s or *%s files found in %s",
+				bundle.BuildResultSuffix, bundle.VerificationResultSuffix, target)
+		}
+		for _, rf := range bun.Results {
+			b, err := readReleaseBuild(filepath.Join(target, rf.Path), rf.Data)
+			if err != nil {
+				return nil, err
+			}
+			builds = append(builds, b)
+		}
+	}
+	return builds, nil
+}
+
+func readReleaseBuild(name string, data []byte) (build.ReleaseBuild, error) {
+	r, vr, err := readResultOrVerification(name, data)
+	return build.ReleaseBuild{Name: name, Result: r, Verification: vr}, err
+}
+
+// selectRelease returns the builds of the product version chosen by the
+// -product and -version flags, which must match exactly one product version.
+func (opts *manifestOpts) selectRelease(builds []build.ReleaseBuild) ([]build.ReleaseBuild, error) {
+	var selected []build.ReleaseBuild
+	releases := map[string]bool{}
+	for _, b := range builds {
+		p := b.Result.Config.Product
+		if (opts.product != "" && p.Name != opts.product) || (opts.version != "" && p.Version.Full != opts.version) {
+			continue
+		}
+		releases[p.Name+" "+p.Version.Full] = true
+		selected = append(selected, b)
+	}
+	switch len(releases) {
+	case 0:
+		return nil, fmt.Errorf("no results found for product %q version %q", opts.product, opts.version)
+	case 1:
+		if ignored := len(builds) - len(selected); ignored != 0 {
+			opts.log("Ignoring %d results for other product versions", ignored)
+		}
+		return selected, nil
+	}
+	var list []string
+	for r := range releases {
+		list = append(list, r)
+	}
+	sort.Strings(list)
+	return nil, fmt.Errorf("found results for %d product versions (%s); use -product and -version to choose one",
+		len(list), strings.Join(list, ", "))
+}
+
+// expectedPlatforms returns the platforms from -platforms, or those declared
+// for product in the products manifest.
+func (opts *manifestOpts) expectedPlatforms(product string) ([]string, error) {
+	if opts.productsManifest == "" {
+		var platforms []string
+		for _, p := range strings.Split(opts.platforms, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				platforms = append(platforms, p)
+			}
+		}
+		return platforms, nil
+	}
+	m, err := manifest.Load(opts.productsManifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range m.Products {
+		if p.Name == product {
+			return p.Platforms, nil
+		}
+	}
+	return nil, fmt.Errorf("product %q is not declared in %s", product, opts.productsManifest)
+}
+
+// write writes the manifest, signing it if -sign-key is set.
+func (opts *manifestOpts) write(m *build.ReleaseManifest) error {
+	path := opts.outFile
+	if path == "" {
+		path = fmt.Sprintf("%s_%s_manifest.json", m.Product, m.Version)
+	}
+	if opts.signKey == "" {
+		if err := json.WriteFile(path, m); err != nil {
+			return err
+		}
+		opts.log("Manifest written to %s", path)
+		return nil
+	}
+	key, err := signing.ReadPrivateKey(opts.signKey)
+	if err != nil {
+		return err
+	}
+	m.PublicKey = signing.PublicKey(key)
+	var buf bytes.Buffer
+	if err := json.Write(&buf, m); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".sig", []byte(signing.Sign(key, buf.Bytes())+"\n"), 0644); err != nil {
+		return err
+	}
+	opts.log("Signed manifest written to %s, signature written to %s.sig", path, path)
+	return nil
+}
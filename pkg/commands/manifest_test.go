@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/actions-go-build/internal/signing"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/json"
+)
+
+func TestManifestOpts_readBuilds(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.buildresult.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	br := filepath.Join(dir, "a.buildresult.json")
+	vr := filepath.Join(dir, "release", "a.verificationresult.json")
+	if err := os.MkdirAll(filepath.Dir(vr), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(br, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	vrData := `{"Primary":` + string(data) + `,"Verification":null,"ReproducedCorrectly":true}`
+	if err := os.WriteFile(vr, []byte(vrData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := manifestOpts{targets: []string{br, filepath.Dir(vr)}}
+	got, err := opts.readBuilds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d builds; want 2", len(got))
+	}
+	if got[0].Name != br || got[0].Verification != nil {
+		t.Errorf("got %q with verification %v; want %q without", got[0].Name, got[0].Verification, br)
+	}
+	if got[1].Name != vr || got[1].Verification == nil || !got[1].Verification.ReproducedCorrectly {
+		t.Errorf("got %q with verification %v; want %q reproduced", got[1].Name, got[1].Verification, vr)
+	}
+}
+
+func TestManifestOpts_selectRelease(t *testing.T) {
+	releaseBuild := func(name, version string) build.ReleaseBuild {
+		r := &build.Result{}
+		r.Config.Product.Name, r.Config.Product.Version.Full = name, version
+		return build.ReleaseBuild{Name: name + "_" + version, Result: r}
+	}
+	builds := []build.ReleaseBuild{releaseBuild("lockbox", "1.0.0"), releaseBuild("lockbox", "1.1.0"), releaseBuild("vault", "1.0.0")}
+	cases := []struct {
+		product, version string
+		want             []string
+		wantErr          string
+	}{
+		{"lockbox", "1.1.0", []string{"lockbox_1.1.0"}, ""},
+		{"vault", "", []string{"vault_1.0.0"}, ""},
+		{"lockbox", "", nil, "found results for 2 product versions (lockbox 1.0.0, lockbox 1.1.0)"},
+		{"", "", nil, "found results for 3 product versions"},
+		{"nomad", "", nil, `no results found for product "nomad"`},
+	}
+	for _, c := range cases {
+		opts := manifestOpts{product: c.product, version: c.version}
+		got, err := opts.selectRelease(builds)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s %s: got error %v; want it to contain %q", c.product, c.version, err, c.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, b := range got {
+			names = append(names, b.Name)
+		}
+		if diff := cmp.Diff(c.want, names); diff != "" {
+			t.Errorf("%s %s: %s", c.product, c.version, diff)
+		}
+	}
+}
+
+func TestManifestOpts_expectedPlatforms(t *testing.T) {
+	products := filepath.Join(t.TempDir(), "products.json")
+	if err := os.WriteFile(products, []byte(`{"products": [{"name": "lockbox", "platforms": ["linux/amd64", "darwin/arm64"]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		opts manifestOpts
+		want []string
+	}{
+		{manifestOpts{}, nil},
+		{manifestOpts{platforms: "linux/amd64, darwin/arm64,"}, []string{"linux/amd64", "darwin/arm64"}},
+		{manifestOpts{productsManifest: products}, []string{"linux/amd64", "darwin/arm64"}},
+	} {
+		got, err := c.opts.expectedPlatforms("lockbox")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Error(diff)
+		}
+	}
+	opts := manifestOpts{productsManifest: products}
+	if _, err := opts.expectedPlatforms("vault"); err == nil {
+		t.Errorf("got nil error for a product not in the products manifest")
+	}
+}
+
+func TestManifestOpts_write_signed(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "manifest.json")
+	opts := manifestOpts{outFile: out, signKey: keyPath}
+	if err := opts.write(&build.ReleaseManifest{Product: "lockbox", Version: "1.2.3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := os.ReadFile(out + ".sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := json.ReadBytes[build.ReleaseManifest](data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := signing.PublicKey(key); m.PublicKey != want {
+		t.Errorf("got public key %q; want %q", m.PublicKey, want)
+	}
+	if err := signing.Verify(m.PublicKey, data, strings.TrimSpace(string(sig))); err != nil {
+		t.Errorf("verifying manifest signature: %s", err)
+	}
+}
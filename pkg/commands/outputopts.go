@@ -63,6 +63,38 @@ func (p *output) result(what string, r Result) error {
 	return nil
 }
 
+// results is like result, but for a list of results each identified by
+// the corresponding entry in names. JSON output is a list of results.
+func (p *output) results(what string, names []string, rs []Result) error {
+	var failed []string
+	for i, r := range rs {
+		if r.Error() != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", names[i], r.Error()))
+		}
+	}
+	if dumped, err := p.maybeDumpJSON(rs); err != nil {
+		return err
+	} else if !dumped {
+		for i, r := range rs {
+			var cached string
+			if r.IsFromCache() {
+				cached = " (cached)"
+			}
+			if r.Error() == nil {
+				p.loud("%s %s succeeded%s", names[i], what, cached)
+			}
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("%s failed for %d of %d product builds:\n%s", what, len(failed), len(rs), strings.Join(failed, "\n"))
+	}
+	if p.json || p.jsonStdErr {
+		return nil
+	}
+	p.loud("%s succeeded for all %d product builds; use the -json flag to see the full results.", what, len(rs))
+	return nil
+}
+
 func (p *output) maybeDumpJSON(v any) (bool, error) {
 	if p.json {
 		return true, dumpJSON(os.Stdout, v)
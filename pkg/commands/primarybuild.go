@@ -36,6 +36,9 @@ func (opts *pbOpts) ownFlags(fs *flag.FlagSet) {}
 func (opts *pbOpts) Init() error {
 	opts.buildFlags.logOpts = opts.logOpts
 	opts.output.logOpts = opts.logOpts
+	if err := opts.buildFlags.config.Init(); err != nil {
+		return err
+	}
 	var err error
 	opts.buildConfig, err = opts.primaryBuildConfig()
 	return err
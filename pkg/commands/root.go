@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"os"
 
 	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
@@ -13,6 +14,13 @@ import (
 var stdout = os.Stdout
 var stderr = os.Stderr
 
+// ctx is the context builds run in. Cancelling it stops any running build.
+var ctx = context.Background()
+
+// SetContext sets the context builds run in, e.g. so that they can be
+// cancelled when the process receives SIGINT.
+func SetContext(c context.Context) { ctx = c }
+
 // Root is the root command of the whole CLI. It is given the name "go" so that
 // when this CLI is incorporated into a parent CLI, the commands within will be
 // rooted at "go". E.g. "go-build", "go-build primary", "go-build verification".
@@ -10,6 +10,7 @@ import (
 	"os"
 	"text/template"
 
+	"github.com/hashicorp/actions-go-build/pkg/build"
 	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
 	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
 	"github.com/hashicorp/composite-action-framework-go/pkg/json"
@@ -31,35 +32,75 @@ func (opts *verifyOpts) Flags(fs *flag.FlagSet) {
 }
 
 var Verify = cli.LeafCommand("verify", "verify a build's reproducibility", func(opts *verifyOpts) error {
+	if opts.all {
+		return opts.verifyAll(opts.runAllVerifications)
+	}
+	if opts.bundle != "" {
+		return opts.verifyAll(opts.runBundleVerifications)
+	}
 	result, err := opts.runVerification()
 	if err != nil {
 		return err
 	}
-	if opts.outFile != "" {
-		if err := json.WriteFile(opts.outFile, result); err != nil {
-			return err
-		}
-		opts.log("Result written to %s", opts.outFile)
+	if err := opts.writeResults(result); err != nil {
+		return err
 	}
-	if opts.stepSummary != "" {
-		opts.log("Writing GitHub Step Summary to %s", opts.stepSummary)
-		f, err := fs.Append(opts.stepSummary)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		funcs := template.FuncMap{
-			"json": func(a any) string {
-				s, err := json.String(a)
-				if err != nil {
-					return fmt.Sprintf("<error: %v>", err)
-				}
-				return s
-			},
-		}
-		if err := template.Must(template.New("").Funcs(funcs).Parse(stepSummaryTemplate)).Execute(f, result); err != nil {
-			return err
-		}
+	if err := opts.writeStepSummary(result); err != nil {
+		return err
 	}
 	return opts.output.result("Reproducibility verification", result)
 })
+
+// verifyAll writes and outputs the results of all the verifications run by run.
+func (opts *verifyOpts) verifyAll(run func() ([]string, []*build.VerificationResult, error)) error {
+	names, results, err := run()
+	if err != nil {
+		return err
+	}
+	if err := opts.writeResults(results); err != nil {
+		return err
+	}
+	rs := make([]Result, len(results))
+	for i, r := range results {
+		if err := opts.writeStepSummary(r); err != nil {
+			return err
+		}
+		rs[i] = r
+	}
+	return opts.output.results("Reproducibility verification", names, rs)
+}
+
+// writeResults writes v to the -o file if set.
+func (opts *verifyOpts) writeResults(v any) error {
+	if opts.outFile == "" {
+		return nil
+	}
+	if err := json.WriteFile(opts.outFile, v); err != nil {
+		return err
+	}
+	opts.log("Result written to %s", opts.outFile)
+	return nil
+}
+
+// writeStepSummary appends a GitHub step summary for result if enabled.
+func (opts *verifyOpts) writeStepSummary(result *build.VerificationResult) error {
+	if opts.stepSummary == "" {
+		return nil
+	}
+	opts.log("Writing GitHub Step Summary to %s", opts.stepSummary)
+	f, err := fs.Append(opts.stepSummary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	funcs := template.FuncMap{
+		"json": func(a any) string {
+			s, err := json.String(a)
+			if err != nil {
+				return fmt.Sprintf("<error: %v>", err)
+			}
+			return s
+		},
+	}
+	return template.Must(template.New("").Funcs(funcs).Parse(stepSummaryTemplate)).Execute(f, result)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
+)
+
+type verifyArtifactOpts struct {
+	logOpts
+	output output
+
+	zipFile string
+	// resultFile is either a build result or a verification result.
+	resultFile string
+}
+
+func (opts *verifyArtifactOpts) Flags(fs *flag.FlagSet) {
+	cli.FlagFuncsAll(fs, opts.logOpts.Flags, opts.output.ownFlags)
+}
+
+func (opts *verifyArtifactOpts) Args(args *cli.ArgList) {
+	args.Required(&opts.zipFile, "zip")
+	args.Required(&opts.resultFile, "result")
+}
+
+func (opts *verifyArtifactOpts) Init() error {
+	opts.output.logOpts = opts.logOpts
+	return nil
+}
+
+var VerifyArtifact = cli.LeafCommand("verify-artifact", "check a zip against its build result without rebuilding", func(opts *verifyArtifactOpts) error {
+	r, err := readResultFile(opts.resultFile)
+	if err != nil {
+		return err
+	}
+	av, err := build.VerifyArtifact(opts.zipFile, *r)
+	if err != nil {
+		return err
+	}
+	if !opts.output.json {
+		p := printer{w: os.Stdout, printTitles: true, prefix: "    "}
+		if err := p.artifactVerification(av); err != nil {
+			return err
+		}
+	}
+	return opts.output.result("Artifact verification", av)
+}).WithHelp(`
+Check that a downloaded zip is the one described by a build result, without
+rebuilding it or needing Go installed.
+
+Pass the zip and either a build result or a verification result file (whose
+primary build result is used). The zip's size and SHA256 digest must match
+the result, and the executable inside it must match the recorded size and
+digest. The product name, version, platform, and variant in the zip name,
+and the Go version and platform in the executable's build info, must match
+the build config. If the build instructions inject the product name, version, and
+revision into the product package, and the executable uses it, the executable
+must contain them.
+`)
+
+// readResultFile reads a build result, or the primary build result from a
+// verification result.
+func readResultFile(path string) (*build.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return readResult(path, data)
+}
+
+func (p *printer) artifactVerification(av *build.ArtifactVerification) error {
+	if err := p.title(fmt.Sprintf("%s %s %s", av.Product, av.Version, av.Platform)); err != nil {
+		return err
+	}
+	return tabWrite(p, av.Checks, func(c build.ArtifactCheck) string {
+		return fmt.Sprintf("%s%s\t%s\twant=%q\tgot=%q", p.prefix, c.Status, c.Name, c.Want, c.Got)
+	})
+}
@@ -24,7 +24,12 @@ import (
 // is done first.
 //
 // Using this primary build result, a verification build is configured and run, and the results
-// are compared.
+// are compared. With -concurrent, the primary and local verification builds run at the same time.
+//
+// The target can also be a directory, zip, or tarball (or the https URL of one), such as a
+// GitHub release or workflow artifact, containing published build or verification results
+// and the zips they describe. Each result is then verified by a remote verification build,
+// and the published zip is checked against the digest recorded in the result.
 //
 // It is possible to skip the verification build by passing the -verification-build-result flag
 // which allows you to directly compare a primary and verification build result which have
@@ -32,9 +37,15 @@ import (
 // multiple results in different jobs and then compare them in another job.
 type verifyish struct {
 	buildish
+	allFlag
+	variance                    varianceFlags
 	staggerTime                 time.Duration
 	verificationBuildResultFile string
 
+	// bundle is set when the target is a directory, archive, or archive URL
+	// containing published results, each of which is verified in turn.
+	bundle string
+
 	primary      build.ResultSource
 	verification build.ResultSource
 }
@@ -42,16 +53,82 @@ type verifyish struct {
 func (v *verifyish) Flags(fs *flag.FlagSet) {
 	v.buildish.Flags(fs)
 	fs.DurationVar(&v.staggerTime, "staggertime", 5*time.Second, "minimum time to wait after start of primary build")
+	fs.BoolVar(&v.buildFlags.concurrent, "concurrent", false, "run the primary and local verification builds at the same time")
 	fs.StringVar(&v.verificationBuildResultFile, "verification-build-result", "", "load verification build result from file")
+	v.buildFlags.sourceFlag(fs)
+	v.variance.Flags(fs)
+	v.allFlag.Flags(fs)
 }
 
 func (v *verifyish) Init() error {
 	if err := v.buildish.Init(); err != nil {
 		return err
 	}
+	var err error
+	if v.buildFlags.variance, err = v.variance.variance(); err != nil {
+		return err
+	}
+	if v.all {
+		// Result sources are set per product by runAllVerifications.
+		if v.verificationBuildResultFile != "" {
+			return fmt.Errorf("-verification-build-result cannot be used with -all")
+		}
+		return nil
+	}
+	if v.bundle, err = bundleLocation(v.target); err != nil {
+		return err
+	}
+	if v.bundle != "" {
+		// Result sources are set per result by runBundleVerifications.
+		if v.verificationBuildResultFile != "" {
+			return fmt.Errorf("-verification-build-result cannot be used when verifying published results")
+		}
+		return nil
+	}
 	return v.setResultSources()
 }
 
+// runAllVerifications runs a primary build and local verification build for
+// every product in the products manifest, and compares each pair.
+func (v *verifyish) runAllVerifications() ([]string, []*build.VerificationResult, error) {
+	pbs, err := v.productBuilds()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, len(pbs))
+	results := make([]*build.VerificationResult, len(pbs))
+	for i, pb := range pbs {
+		pm, err := pb.newPrimaryManager(v.buildFlags, "primary build")
+		if err != nil {
+			return nil, nil, err
+		}
+		// When running concurrently, the verifier runs the primary build
+		// alongside the verification build.
+		var primary build.ResultSource = pm
+		startAfter := time.Now().Add(v.staggerTime)
+		if !v.buildFlags.concurrent {
+			r, err := pm.Result()
+			if err != nil {
+				return nil, nil, err
+			}
+			primary, startAfter = r, r.Meta.Start.Add(v.staggerTime)
+		}
+		vm, err := pb.newLocalVerificationManager(v.buildFlags, startAfter, "verification build")
+		if err != nil {
+			return nil, nil, err
+		}
+		verifier, err := v.buildFlags.newVerifier(primary, vm, build.WithLogPrefix(pb.name))
+		if err != nil {
+			return nil, nil, err
+		}
+		if results[i], err = verifier.Verify(); err != nil {
+			return nil, nil, err
+		}
+		names[i] = pb.name
+	}
+	return names, results, nil
+}
+
 func (v *verifyish) runVerification() (*build.VerificationResult, error) {
 	verifier, err := v.buildish.buildFlags.newVerifier(v.primary, v.verification)
 	if err != nil {
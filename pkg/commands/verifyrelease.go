@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/hashicorp/actions-go-build/internal/bundle"
+	"github.com/hashicorp/actions-go-build/internal/download"
+	"github.com/hashicorp/actions-go-build/pkg/build"
+	"github.com/hashicorp/composite-action-framework-go/pkg/cli"
+	"github.com/hashicorp/composite-action-framework-go/pkg/fs"
+	"github.com/hashicorp/composite-action-framework-go/pkg/json"
+)
+
+//go:embed templates/releasesummary.md.tmpl
+var releaseSummaryTemplate string
+
+type verifyReleaseOpts struct {
+	logOpts
+	buildFlags  buildFlags
+	output      output
+	parallel    int
+	outFile     string
+	stepSummary string
+
+	// target is a directory or archive of results, or a manifest listing them.
+	target string
+}
+
+func (opts *verifyReleaseOpts) Flags(fs *flag.FlagSet) {
+	cli.FlagFuncsAll(fs, opts.logOpts.Flags, opts.buildFlags.ownFlags, opts.output.ownFlags)
+	fs.IntVar(&opts.parallel, "parallel", 4, "maximum number of verification builds to run at the same time")
+	fs.StringVar(&opts.outFile, "o", "", "write the report json to this file")
+	fs.StringVar(&opts.stepSummary, "github-step-summary", os.Getenv("GITHUB_STEP_SUMMARY"), "write a github step summary to this file")
+}
+
+func (opts *verifyReleaseOpts) Args(args *cli.ArgList) {
+	args.Required(&opts.target, "results")
+}
+
+func (opts *verifyReleaseOpts) Init() error {
+	opts.buildFlags.logOpts = opts.logOpts
+	opts.output.logOpts = opts.logOpts
+	if opts.parallel < 1 {
+		return fmt.Errorf("-parallel must be at least 1")
+	}
+	// Prefix the output of builds running at the same time, and give them
+	// their own Go build caches.
+	opts.buildFlags.concurrent = opts.parallel > 1
+	return opts.buildFlags.config.Init()
+}
+
+var VerifyRelease = cli.LeafCommand("verify-release", "verify every build in a release", func(opts *verifyReleaseOpts) error {
+	results, err := opts.readResults()
+	if err != nil {
+		return err
+	}
+	report := opts.verify(results)
+	if opts.outFile != "" {
+		if err := json.WriteFile(opts.outFile, report); err != nil {
+			return err
+		}
+		opts.log("Report written to %s", opts.outFile)
+	}
+	if err := opts.writeStepSummary(report); err != nil {
+		return err
+	}
+	for _, p := range report.Platforms {
+		status := "reproduced"
+		if !p.ReproducedCorrectly {
+			status = "NOT reproduced: " + p.ErrorMessage
+		}
+		opts.loud("%s %s", p.Name, status)
+	}
+	return opts.output.result("Release verification", report)
+}).WithHelp(`
+Verify every build in a release, by running a remote verification build for
+each primary build result and comparing them.
+
+Pass a directory, zip, or tarball containing *.buildresult.json or
+*.verificationresult.json files (at any depth), or a manifest file listing
+one result file path (relative to the manifest) or https URL per line. Blank
+lines and lines starting with # are ignored. The primary build result of
+each verification result is used.
+
+Up to -parallel verification builds run at the same time, and the source
+archive for each revision is only downloaded once. The report lists whether
+each platform reproduced, with variants such as linux/amd64_v3 listed
+separately. Use -o to write it as JSON, and it's written as a table to the
+GitHub step summary if there is one.
+`)
+
+// releaseResult is a primary build result to verify.
+type releaseResult struct {
+	name   string
+	result *build.Result
+}
+
+// readResults reads the results in the target directory or archive, or
+// listed in the target manifest.
+func (opts *verifyReleaseOpts) readResults() ([]releaseResult, error) {
+	isDir, err := fs.DirExists(opts.target)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir && !bundle.IsArchive(opts.target) {
+		return opts.readManifest()
+	}
+	b, err := bundle.Read(opts.target)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Results) == 0 {
+		return nil, fmt.Errorf("no *%s or *%s files found in %s",
+			bundle.BuildResultSuffix, bundle.VerificationResultSuffix, opts.target)
+	}
+	results := make([]releaseResult, len(b.Results))
+	for i, rf := range b.Results {
+		r, err := readCleanResult(rf.Path, rf.Data)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = releaseResult{name: rf.Path, result: r}
+	}
+	return results, nil
+}
+
+// readManifest reads the results listed in the target manifest.
+func (opts *verifyReleaseOpts) readManifest() ([]releaseResult, error) {
+	entries, err := readManifestEntries(opts.target)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no results listed in %s", opts.target)
+	}
+	d := download.New(download.WithLogFunc(opts.logFunc()), download.WithMaxSize(maxConfigSize))
+	results := make([]releaseResult, len(entries))
+	for i, e := range entries {
+		var data []byte
+		if u, err := url.Parse(e); err == nil && u.Scheme == "https" {
+			data, err = d.Bytes(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			path := e
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(opts.target), path)
+			}
+			if data, err = os.ReadFile(path); err != nil {
+				return nil, err
+			}
+		}
+		r, err := readCleanResult(e, data)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = releaseResult{name: e, result: r}
+	}
+	return results, nil
+}
+
+// readManifestEntries returns the non-blank, non-comment lines in the
+// manifest at path.
+func readManifestEntries(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries, s.Err()
+}
+
+// verify runs up to opts.parallel verifications at a time, sharing source
+// archive downloads between them.
+func (opts *verifyReleaseOpts) verify(results []releaseResult) *releaseReport {
+	report := &releaseReport{Platforms: make([]releasePlatform, len(results))}
+	archives := build.NewSourceArchives()
+	sem := make(chan struct{}, opts.parallel)
+	var wg sync.WaitGroup
+	for i, rr := range results {
+		wg.Add(1)
+		go func(i int, rr releaseResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			vr, err := opts.verifyOne(rr, archives)
+			report.Platforms[i] = newReleasePlatform(rr, vr, err)
+		}(i, rr)
+	}
+	wg.Wait()
+	report.summarise(results)
+	return report
+}
+
+func (opts *verifyReleaseOpts) verifyOne(rr releaseResult, archives *build.SourceArchives) (*build.VerificationResult, error) {
+	vm, err := opts.buildFlags.newRemoteVerificationManager(rr.result.Config,
+		build.WithLogPrefix(rr.name+" verification build"), build.WithSourceArchives(archives))
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := opts.buildFlags.newVerifier(rr.result, vm, build.WithLogPrefix(rr.name))
+	if err != nil {
+		return nil, err
+	}
+	return verifier.Verify()
+}
+
+// releaseReport is the outcome of verifying every build in a release.
+type releaseReport struct {
+	Product, Version string
+	// Platforms are in the order the results were read.
+	Platforms           []releasePlatform
+	Passed, Failed      int
+	ReproducedCorrectly bool
+}
+
+// releasePlatform is the outcome of verifying a single build in a release.
+type releasePlatform struct {
+	// Name identifies the result file.
+	Name string
+	// Platform is "os/arch", followed by the variant if there is one, so
+	// that each variant of a platform has its own row.
+	Platform            string
+	Zip                 string
+	ReproducedCorrectly bool
+	ErrorMessage        string                    `json:",omitempty"`
+	Verification        *build.VerificationResult `json:",omitempty"`
+}
+
+func newReleasePlatform(rr releaseResult, vr *build.VerificationResult, err error) releasePlatform {
+	p := releasePlatform{
+		Name:         rr.name,
+		Platform:     rr.result.Config.Parameters.Platform(),
+		Zip:          rr.result.Zip.Name,
+		Verification: vr,
+	}
+	switch {
+	case err != nil:
+		p.ErrorMessage = err.Error()
+	case vr.Error() != nil:
+		p.ErrorMessage = vr.ErrorMessage
+	default:
+		p.ReproducedCorrectly = vr.ReproducedCorrectly
+	}
+	return p
+}
+
+// summarise sets the product, version, and totals. The product and version
+// are left empty if the results aren't all for the same ones.
+func (r *releaseReport) summarise(results []releaseResult) {
+	products, versions := map[string]bool{}, map[string]bool{}
+	for _, rr := range results {
+		p := rr.result.Config.Product
+		products[p.Name], versions[p.Version.Full] = true, true
+		r.Product, r.Version = p.Name, p.Version.Full
+	}
+	if len(products) != 1 {
+		r.Product = ""
+	}
+	if len(versions) != 1 {
+		r.Version = ""
+	}
+	r.Passed, r.Failed = 0, 0
+	for _, p := range r.Platforms {
+		if p.ReproducedCorrectly {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+	}
+	r.ReproducedCorrectly = r.Failed == 0 && len(r.Platforms) != 0
+}
+
+func (r *releaseReport) Error() error {
+	if r.ReproducedCorrectly {
+		return nil
+	}
+	var failed []string
+	for _, p := range r.Platforms {
+		if !p.ReproducedCorrectly {
+			failed = append(failed, fmt.Sprintf("%s: %s", p.Name, p.ErrorMessage))
+		}
+	}
+	return fmt.Errorf("%d of %d builds not reproduced: %s", r.Failed, len(r.Platforms), strings.Join(failed, "; "))
+}
+
+func (r *releaseReport) IsFromCache() bool { return false }
+
+// writeStepSummary appends a GitHub step summary for report if enabled.
+func (opts *verifyReleaseOpts) writeStepSummary(report *releaseReport) error {
+	if opts.stepSummary == "" {
+		return nil
+	}
+	opts.log("Writing GitHub Step Summary to %s", opts.stepSummary)
+	f, err := fs.Append(opts.stepSummary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeReleaseSummary(f, report)
+}
+
+func writeReleaseSummary(w io.Writer, report *releaseReport) error {
+	return template.Must(template.New("").Parse(releaseSummaryTemplate)).Execute(w, report)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/actions-go-build/pkg/build"
+)
+
+func TestVerifyReleaseOpts_readResults(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.buildresult.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"darwin/a.buildresult.json", "linux/b.buildresult.json"} {
+		p := filepath.Join(dir, "release", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abs := filepath.Join(dir, "release", "linux", "b.buildresult.json")
+	manifest := filepath.Join(dir, "results.txt")
+	list := "# The release.\nrelease/darwin/a.buildresult.json\n\n  " + abs + "\n"
+	if err := os.WriteFile(manifest, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for target, want := range map[string][]string{
+		filepath.Join(dir, "release"): {"darwin/a.buildresult.json", "linux/b.buildresult.json"},
+		manifest:                      {"release/darwin/a.buildresult.json", abs},
+	} {
+		opts := verifyReleaseOpts{target: target}
+		got, err := opts.readResults()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, rr := range got {
+			names = append(names, rr.name)
+			if rr.result.Config.Product.Name != "actions-go-build" {
+				t.Errorf("%s: got product %q", rr.name, rr.result.Config.Product.Name)
+			}
+		}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("readResults(%s) got %q; want %q", target, names, want)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := verifyReleaseOpts{target: empty}
+	if _, err := opts.readResults(); err == nil {
+		t.Errorf("got nil error reading an empty manifest")
+	}
+}
+
+func testReleaseReport() *releaseReport {
+	result := func(os, arch, goamd64 string) releaseResult {
+		r := &build.Result{}
+		r.Config.Product.Name = "lockbox"
+		r.Config.Product.Version.Full = "1.2.3"
+		r.Config.Parameters.OS, r.Config.Parameters.Arch = os, arch
+		r.Config.Parameters.GOAMD64 = goamd64
+		platform := os + "_" + arch
+		if goamd64 != "" {
+			platform += "_" + goamd64
+		}
+		r.Zip.Name = "lockbox_1.2.3_" + platform + ".zip"
+		return releaseResult{name: platform + ".buildresult.json", result: r}
+	}
+	results := []releaseResult{result("linux", "amd64", ""), result("darwin", "arm64", ""), result("linux", "amd64", "v3")}
+	report := &releaseReport{Platforms: []releasePlatform{
+		newReleasePlatform(results[0], &build.VerificationResult{ReproducedCorrectly: true}, nil),
+		newReleasePlatform(results[1], &build.VerificationResult{ErrorMessage: "zip hashes differ"}, nil),
+		newReleasePlatform(results[2], nil, errors.New("build failed")),
+	}}
+	report.summarise(results)
+	return report
+}
+
+func TestReleaseReport(t *testing.T) {
+	report := testReleaseReport()
+	if report.Product != "lockbox" || report.Version != "1.2.3" {
+		t.Errorf("got product %q version %q", report.Product, report.Version)
+	}
+	if report.Passed != 1 || report.Failed != 2 || report.ReproducedCorrectly {
+		t.Errorf("got passed=%d failed=%d reproduced=%t", report.Passed, report.Failed, report.ReproducedCorrectly)
+	}
+	want := "2 of 3 builds not reproduced: darwin_arm64.buildresult.json: zip hashes differ; linux_amd64_v3.buildresult.json: build failed"
+	if err := report.Error(); err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %q", err, want)
+	}
+}
+
+func TestWriteReleaseSummary(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReleaseSummary(&buf, testReleaseReport()); err != nil {
+		t.Fatal(err)
+	}
+	want := "## :x: `lockbox` 1.2.3 release: 1 of 3 builds reproduced\n" +
+		"\n" +
+		"| Platform | Zip | Reproduced | Error |\n" +
+		"|----------|-----|------------|-------|\n" +
+		"| linux/amd64 | `lockbox_1.2.3_linux_amd64.zip` | :white_check_mark: |  |\n" +
+		"| darwin/arm64 | `lockbox_1.2.3_darwin_arm64.zip` | :x: | zip hashes differ |\n" +
+		"| linux/amd64_v3 | `lockbox_1.2.3_linux_amd64_v3.zip` | :x: | build failed |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
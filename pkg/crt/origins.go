@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package crt
+
+import "fmt"
+
+// Origin describes where a resolved config value came from.
+type Origin struct {
+	Source   string
+	Warnings []string `json:",omitempty"`
+}
+
+// Origins records the Origin of resolved config values, keyed by the
+// environment variable name used to set them. A nil Origins ignores
+// everything recorded, so callers that don't care can pass nil.
+type Origins map[string]*Origin
+
+// Set records that the value of key came from source.
+func (o Origins) Set(key, source string, a ...any) {
+	if o == nil {
+		return
+	}
+	o.get(key).Source = fmt.Sprintf(source, a...)
+}
+
+// Warn adds a warning about the value of key.
+func (o Origins) Warn(key, format string, a ...any) {
+	if o == nil {
+		return
+	}
+	e := o.get(key)
+	e.Warnings = append(e.Warnings, fmt.Sprintf(format, a...))
+}
+
+func (o Origins) get(key string) *Origin {
+	e, ok := o[key]
+	if !ok {
+		e = &Origin{}
+		o[key] = e
+	}
+	return e
+}
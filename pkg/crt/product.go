@@ -56,7 +56,13 @@ func (p Product) IsDirty() bool {
 }
 
 func (p Product) Init(rc RepoContext) (Product, error) {
-	return p.trimSpace().setDefaults(rc)
+	return p.InitWithOrigins(rc, nil)
+}
+
+// InitWithOrigins is like Init, but also records where each defaulted value
+// came from in o.
+func (p Product) InitWithOrigins(rc RepoContext, o Origins) (Product, error) {
+	return p.trimSpace().setDefaults(rc, o)
 }
 
 func (p Product) RevisionTimestamp() (time.Time, error) {
@@ -96,9 +102,10 @@ func (p Product) trimSpace() Product {
 	return p
 }
 
-func (p Product) setDefaults(rc RepoContext) (Product, error) {
+func (p Product) setDefaults(rc RepoContext, o Origins) (Product, error) {
 	if p.Repository == "" {
 		p.Repository = rc.RepoName
+		o.Set("PRODUCT_REPOSITORY", "repository name")
 	}
 
 	if p.Module == "" {
@@ -106,15 +113,27 @@ func (p Product) setDefaults(rc RepoContext) (Product, error) {
 	}
 
 	if p.Name == "" {
-		p.Name = p.defaultProductName(rc)
+		var source string
+		p.Name, source = p.defaultProductName(rc)
+		o.Set("PRODUCT_NAME", source)
 	}
 
 	p.CoreName = strings.TrimSuffix(p.Name, "-enterprise")
 
 	if p.ExecutableName == "" {
 		p.ExecutableName = p.CoreName
+		o.Set("BIN_NAME", "product name without -enterprise suffix")
 	}
 
+	if p.Version.Full == "" {
+		o.Set("PRODUCT_VERSION", "%s", rc.CoreVersionSource)
+		if p.Version.Meta != "" {
+			o.Set("PRODUCT_VERSION", "%s, plus PRODUCT_VERSION_META", rc.CoreVersionSource)
+		}
+	}
+	if rc.CoreVersion.Equal(defaultVersion) {
+		o.Warn("PRODUCT_VERSION", "no VERSION file found; using default %s", defaultVersion)
+	}
 	var err error
 	if p.Version, err = p.Version.InitWithCoreVersion(rc.CoreVersion.String()); err != nil {
 		return p, err
@@ -124,16 +143,21 @@ func (p Product) setDefaults(rc RepoContext) (Product, error) {
 	p.RevisionTime = rc.CommitTime.UTC().Format(time.RFC3339)
 	p.SourceHash = rc.SourceHash
 	p.DirtyFiles = rc.DirtyFiles
+	o.Set("PRODUCT_REVISION", "HEAD commit")
+	o.Set("PRODUCT_REVISION_TIME", "HEAD commit author time")
+	if n := len(rc.DirtyFiles); n != 0 {
+		o.Warn("PRODUCT_REVISION", "worktree has %d dirty files, so the build isn't of this revision alone", n)
+	}
 	return p, nil
 }
 
-func (p Product) defaultProductName(rc RepoContext) string {
+func (p Product) defaultProductName(rc RepoContext) (name, source string) {
 	// If we're in the repo root, use the repo name.
 	if rc.Dir == rc.RootDir {
-		return filepath.Base(p.Repository)
+		return filepath.Base(p.Repository), "repository name"
 	}
 	// Otherwise use the subdirectory name.
-	return filepath.Base(rc.Dir)
+	return filepath.Base(rc.Dir), "product directory name"
 }
 
 func maybeErr(err error, format string, args ...any) error {
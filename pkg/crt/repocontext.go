@@ -4,12 +4,15 @@
 package crt
 
 import (
+	"crypto/sha1"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -27,8 +30,12 @@ type RepoContext struct {
 	CommitSHA   string
 	CommitTime  time.Time
 	CoreVersion version.Version
-	SourceHash  string
-	DirtyFiles  []string `json:",omitempty"`
+	// VersionStrategy is the strategy that produced CoreVersion.
+	VersionStrategy string
+	// CoreVersionSource describes where CoreVersion was read from.
+	CoreVersionSource string `json:",omitempty"`
+	SourceHash        string
+	DirtyFiles        []string `json:",omitempty"`
 }
 
 // IsDirty returns true if the worktree is dirty, ignoring
@@ -37,8 +44,11 @@ func (rc RepoContext) IsDirty() bool {
 	return rc.SourceHash == rc.CommitSHA
 }
 
-// GetRepoContext reads the repository context from the directory specified.
-func GetRepoContext(dir string, ignoreDirs []string) (RepoContext, error) {
+// GetRepoContext reads the repository context from the directory specified,
+// using vs to determine the core version. If any scope paths are given
+// (relative to the repository root), only dirty files inside them are
+// considered when calculating the source hash.
+func GetRepoContext(dir string, ignoreDirs []string, vs VersionStrategy, scope ...string) (RepoContext, error) {
 	repoName, err := getRepoName(dir)
 	if err != nil {
 		return RepoContext{}, err
@@ -57,7 +67,7 @@ func GetRepoContext(dir string, ignoreDirs []string) (RepoContext, error) {
 	sha := commits[0].ID
 	ts := commits[0].AuthorTime
 
-	v, err := getCoreVersion(dir)
+	v, err := getCoreVersion(dir, vs)
 	if err != nil {
 		return RepoContext{}, err
 	}
@@ -66,6 +76,11 @@ func GetRepoContext(dir string, ignoreDirs []string) (RepoContext, error) {
 	if err != nil {
 		return RepoContext{}, err // blah
 	}
+	if len(scope) != 0 {
+		if worktreeState, err = scopeWorktreeState(repo.RootDir(), worktreeState, scope); err != nil {
+			return RepoContext{}, err
+		}
+	}
 
 	var moduleName string
 	goDotMod := filepath.Join(dir, "go.mod")
@@ -86,15 +101,17 @@ func GetRepoContext(dir string, ignoreDirs []string) (RepoContext, error) {
 	}
 
 	return RepoContext{
-		RepoName:    repoName,
-		ModuleName:  moduleName,
-		Dir:         dir,
-		RootDir:     repo.RootDir(),
-		CommitSHA:   sha,
-		CommitTime:  ts,
-		CoreVersion: *v,
-		SourceHash:  worktreeState.SourceHash,
-		DirtyFiles:  worktreeState.DirtyFiles,
+		RepoName:          repoName,
+		ModuleName:        moduleName,
+		Dir:               dir,
+		RootDir:           repo.RootDir(),
+		CommitSHA:         sha,
+		CommitTime:        ts,
+		CoreVersion:       *v,
+		VersionStrategy:   vs.String(),
+		CoreVersionSource: describeVersionSource(dir, vs, v),
+		SourceHash:        worktreeState.SourceHash,
+		DirtyFiles:        worktreeState.DirtyFiles,
 	}, nil
 }
 
@@ -120,9 +137,74 @@ func getWorktreeState(dir string, ignoreDirs []string) (*git.WorktreeState, erro
 	return &s, nil
 }
 
+// scopeWorktreeState drops dirty files outside of scope from ws. If any are
+// dropped, the source hash is recalculated from the remaining dirty files in
+// the same way as git.WorktreeState, or reset to the head commit ID if there
+// are none left.
+func scopeWorktreeState(rootDir string, ws *git.WorktreeState, scope []string) (*git.WorktreeState, error) {
+	var inScope []string
+	for _, f := range ws.DirtyFiles {
+		if pathInScope(f, scope) {
+			inScope = append(inScope, f)
+		}
+	}
+	if len(inScope) == len(ws.DirtyFiles) {
+		return ws, nil
+	}
+	out := *ws
+	out.DirtyFiles = inScope
+	if len(inScope) == 0 {
+		out.SourceHash = ws.Head.ID
+		return &out, nil
+	}
+	summer := sha1.New()
+	fmt.Fprintf(summer, "head: %s\n", ws.Head.ID)
+	for _, f := range inScope {
+		if err := writeFileEntry(summer, rootDir, f); err != nil {
+			return nil, err
+		}
+	}
+	out.SourceHash = fmt.Sprintf("%x", summer.Sum(nil))
+	return &out, nil
+}
+
+func pathInScope(name string, scope []string) bool {
+	name = filepath.ToSlash(name)
+	for _, s := range scope {
+		if s == "." || name == s || strings.HasPrefix(name, strings.TrimSuffix(s, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileEntry writes the name and contents of the file at name (relative
+// to rootDir) to w, or just its name if it has been deleted.
+func writeFileEntry(w io.Writer, rootDir, name string) error {
+	path := filepath.Join(rootDir, name)
+	exists, err := fs.FileExists(path)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := fmt.Fprintf(w, "deleted: %q\n", name)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "changed: %q\n", name); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
 func makeIgnorePatterns(dirNames []string) []string {
 	for i, d := range dirNames {
-		dirNames[i] = fmt.Sprintf("^%s\\/", d)
+		dirNames[i] = fmt.Sprintf("^%s/", regexp.QuoteMeta(d))
 	}
 	return dirNames
 }
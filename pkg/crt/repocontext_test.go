@@ -4,7 +4,13 @@
 package crt
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/composite-action-framework-go/pkg/git"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
 )
 
 func TestGetRepoNameFromRemoteURL(t *testing.T) {
@@ -69,3 +75,65 @@ func TestGetRepoNameFromRemoteURL(t *testing.T) {
 		})
 	}
 }
+
+func TestScopeWorktreeState(t *testing.T) {
+
+	scope := []string{"app", "shared/lib"}
+
+	cases := []struct {
+		desc      string
+		dirty     []string
+		wantDirty []string
+		// wantHead is true if the source hash should equal the head commit ID.
+		wantHead bool
+	}{
+		{"clean", nil, nil, true},
+		{"outside scope", []string{"other/main.go", "README.md"}, nil, true},
+		{"inside scope", []string{"app/main.go", "shared/lib/lib.go"}, []string{"app/main.go", "shared/lib/lib.go"}, false},
+		{"mixed", []string{"app/main.go", "other/main.go"}, []string{"app/main.go"}, false},
+		{"prefix is not scope", []string{"application/main.go", "shared/library.go"}, nil, true},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := tmp.Dir(t)
+			runGit(t, dir, "init", "-q")
+			runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+			for _, f := range c.dirty {
+				path := filepath.Join(dir, f)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			repo, err := git.Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			head, err := repo.HeadCommit()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ws := &git.WorktreeState{Head: head, SourceHash: "unscoped", DirtyFiles: c.dirty}
+			if len(c.dirty) == 0 {
+				ws.SourceHash = head.ID
+			}
+			got, err := scopeWorktreeState(dir, ws, scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got.DirtyFiles, c.wantDirty); diff != "" {
+				t.Error(diff)
+			}
+			if gotHead := got.SourceHash == head.ID; gotHead != c.wantHead {
+				t.Errorf("got source hash %q (head %q); want head: %t", got.SourceHash, head.ID, c.wantHead)
+			}
+			if !c.wantHead && len(c.wantDirty) != len(c.dirty) && got.SourceHash == "unscoped" {
+				t.Errorf("source hash not recalculated")
+			}
+		})
+	}
+}
@@ -18,13 +18,6 @@ const defaultVersionString = "0.0.0-version-file-missing"
 
 var defaultVersion = version.Must(version.NewVersion(defaultVersionString))
 
-// getCoreVersion exists so that we can add additional version strategies
-// in the future. Currently we're only adding a single strategy, which is
-// to read from a VERSION file.
-func getCoreVersion(dir string) (*version.Version, error) {
-	return getCoreVersionFromVersionFile(dir)
-}
-
 var versionSearchPath = []string{".", ".release", "version", "dev"}
 
 func versionSearchPaths(basedir string) []string {
@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package crt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// VersionPolicyLenient accepts any version go-version can parse. It's the default.
+	VersionPolicyLenient = "lenient"
+	// VersionPolicyRelease enforces the rules in VersionPolicy.Check.
+	VersionPolicyRelease = "release"
+)
+
+const (
+	defaultAllowedPrereleases   = "alpha,beta,rc"
+	defaultAllowedMetadataRegex = `^(ent|hsm|fips1402)(\.(ent|hsm|fips1402))*$`
+)
+
+// strictSemver is the regular expression from semver.org. go-version is more
+// forgiving, e.g. it accepts "1.2" and leading zeros.
+var strictSemver = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// VersionPolicy determines how strictly the product version is validated.
+type VersionPolicy struct {
+	// Mode is either "lenient" (the default) or "release".
+	Mode string `env:"VERSION_POLICY"`
+	// Prereleases is a comma-separated list of allowed prerelease labels. Each label
+	// may be followed by a number, e.g. "rc" allows "rc1" and "rc.2".
+	// Defaults to "alpha,beta,rc".
+	Prereleases string `env:"VERSION_PRERELEASES"`
+	// MetadataPattern is a regular expression that version metadata must match.
+	// Defaults to allowing dot-separated combinations of "ent", "hsm" and "fips1402".
+	MetadataPattern string `env:"VERSION_METADATA_PATTERN"`
+}
+
+// IsRelease returns true if the release policy is in force.
+func (vp VersionPolicy) IsRelease() bool { return vp.Mode == VersionPolicyRelease }
+
+// Validate checks the policy itself is valid.
+func (vp VersionPolicy) Validate() error {
+	switch vp.Mode {
+	case "", VersionPolicyLenient, VersionPolicyRelease:
+	default:
+		return fmt.Errorf("%q is not a valid version policy, must be one of %q (default) or %q",
+			vp.Mode, VersionPolicyLenient, VersionPolicyRelease)
+	}
+	_, _, err := vp.patterns()
+	return err
+}
+
+func (vp VersionPolicy) patterns() (prerelease, metadata *regexp.Regexp, err error) {
+	labels := vp.Prereleases
+	if labels == "" {
+		labels = defaultAllowedPrereleases
+	}
+	var quoted []string
+	for _, l := range strings.Split(labels, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			quoted = append(quoted, regexp.QuoteMeta(l))
+		}
+	}
+	prerelease, err = regexp.Compile(fmt.Sprintf(`^(%s)(\.?[0-9]+)?$`, strings.Join(quoted, "|")))
+	if err != nil {
+		return nil, nil, err
+	}
+	pattern := vp.MetadataPattern
+	if pattern == "" {
+		pattern = defaultAllowedMetadataRegex
+	}
+	metadata, err = regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid version metadata pattern: %w", err)
+	}
+	return prerelease, metadata, nil
+}
+
+// Check returns an error listing every way in which pv, read from the
+// repository described by rc, violates the policy. Lenient policies
+// never return an error.
+//
+// The release policy requires that:
+//   - a VERSION file exists (when using the file version strategy),
+//   - the full version is strictly valid semver,
+//   - any prerelease label is one of the allowed labels,
+//   - any metadata matches the allowed pattern, and
+//   - the core version agrees with the one read from the repository.
+func (vp VersionPolicy) Check(pv ProductVersion, rc RepoContext) error {
+	if !vp.IsRelease() {
+		return nil
+	}
+	prerelease, metadata, err := vp.patterns()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	problem := func(format string, a ...any) { problems = append(problems, fmt.Sprintf(format, a...)) }
+
+	if (rc.VersionStrategy == "" || rc.VersionStrategy == string(VersionFromFile)) && rc.CoreVersion.Equal(defaultVersion) {
+		problem("%s in any of %s", ErrNoVersionFile, strings.Join(versionSearchPath, ", "))
+	}
+	v, err := version.NewSemver(pv.Full)
+	if err != nil || !strictSemver.MatchString(pv.Full) {
+		problem("%q is not valid semver", pv.Full)
+	} else {
+		if p := v.Prerelease(); p != "" && !prerelease.MatchString(p) {
+			problem("prerelease %q not allowed; must be one of %s, optionally followed by a number", p, vp.allowedPrereleases())
+		}
+		if m := v.Metadata(); m != "" && !metadata.MatchString(m) {
+			problem("metadata %q does not match %s", m, metadata)
+		}
+	}
+	if core := rc.CoreVersion.String(); pv.Core != core {
+		problem("core version %q does not match %q from version strategy %q", pv.Core, core, rc.VersionStrategy)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("version %q violates the release version policy:\n%s", pv.Full, strings.Join(problems, "\n"))
+}
+
+func (vp VersionPolicy) allowedPrereleases() string {
+	if vp.Prereleases == "" {
+		return defaultAllowedPrereleases
+	}
+	return vp.Prereleases
+}
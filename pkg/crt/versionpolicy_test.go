@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package crt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestVersionPolicy_Check(t *testing.T) {
+
+	release := VersionPolicy{Mode: VersionPolicyRelease}
+
+	cases := []struct {
+		desc     string
+		policy   VersionPolicy
+		full     string
+		core     string
+		strategy string
+		// want lists substrings of the expected error, or is empty for no error.
+		want []string
+	}{
+		{"lenient allows anything", VersionPolicy{}, "1.2.3-whatever+junk", "0.0.0-version-file-missing", "file", nil},
+		{"release ok", release, "1.2.3", "1.2.3", "file", nil},
+		{"release rc ok", release, "1.2.3-rc1+ent", "1.2.3-rc1", "file", nil},
+		{"release dotted beta ok", release, "1.2.3-beta.2+ent.fips1402", "1.2.3-beta.2", "file", nil},
+		{"release other strategy ok", release, "1.2.3", "1.2.3", "git-tag", nil},
+		{"custom prereleases", VersionPolicy{Mode: VersionPolicyRelease, Prereleases: "dev"}, "1.2.3-dev4", "1.2.3-dev4", "file", nil},
+		{"custom metadata", VersionPolicy{Mode: VersionPolicyRelease, MetadataPattern: "^custom$"}, "1.2.3+custom", "1.2.3", "file", nil},
+		{
+			"missing version file", release, "0.0.0-version-file-missing", "0.0.0-version-file-missing", "file",
+			[]string{"no VERSION file found", `prerelease "version-file-missing" not allowed`},
+		},
+		{"bad prerelease", release, "1.2.3-snapshot", "1.2.3-snapshot", "file", []string{`prerelease "snapshot" not allowed`}},
+		{"bad metadata", release, "1.2.3+local", "1.2.3", "file", []string{`metadata "local" does not match`}},
+		{"core mismatch", release, "1.2.4", "1.2.3", "file", []string{`core version "1.2.4" does not match "1.2.3"`}},
+		{"not semver", release, "1.2", "1.2", "env:V", []string{`"1.2" is not valid semver`}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			pv, err := NewProductVersion("", c.full)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc := RepoContext{
+				CoreVersion:     *version.Must(version.NewVersion(c.core)),
+				VersionStrategy: c.strategy,
+			}
+			err = c.policy.Check(pv, rc)
+			if len(c.want) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("got nil error; want error containing %q", c.want)
+			}
+			for _, w := range c.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("got error %q; want it to contain %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionPolicy_Validate(t *testing.T) {
+
+	cases := []struct {
+		desc    string
+		policy  VersionPolicy
+		wantErr bool
+	}{
+		{"default", VersionPolicy{}, false},
+		{"lenient", VersionPolicy{Mode: "lenient"}, false},
+		{"release", VersionPolicy{Mode: "release"}, false},
+		{"unknown mode", VersionPolicy{Mode: "strict"}, true},
+		{"bad pattern", VersionPolicy{Mode: "release", MetadataPattern: "("}, true},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			err := c.policy.Validate()
+			if gotErr := err != nil; gotErr != c.wantErr {
+				t.Errorf("got error %v; want error: %t", err, c.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package crt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// VersionStrategyKind names a way of determining the core version.
+type VersionStrategyKind string
+
+const (
+	// VersionFromFile reads a VERSION file from one of the versionSearchPath dirs.
+	// This is the default strategy.
+	VersionFromFile VersionStrategyKind = "file"
+	// VersionFromGitTag uses the highest semver tag reachable from HEAD.
+	// If there are commits since that tag, their count is appended to the
+	// prerelease field.
+	VersionFromGitTag VersionStrategyKind = "git-tag"
+	// VersionFromGoConst reads a string constant (or variable) from a Go file.
+	VersionFromGoConst VersionStrategyKind = "go-const"
+	// VersionFromManifest reads a field from a package.json-style JSON file
+	// or a Cargo.toml-style TOML file.
+	VersionFromManifest VersionStrategyKind = "manifest"
+	// VersionFromEnv reads the version from an environment variable.
+	VersionFromEnv VersionStrategyKind = "env"
+)
+
+// VersionStrategy determines how the core version is read from a repository.
+// It's written as a string in one of these forms:
+//
+//	file
+//	git-tag
+//	go-const:<file>[:<name>]   (name defaults to "Version")
+//	manifest:<file>[:<field>]  (field defaults to "version" for JSON, "package.version" for TOML)
+//	env:<name>
+type VersionStrategy struct {
+	Kind VersionStrategyKind
+	// Path is the file read by the go-const and manifest strategies,
+	// relative to the product directory.
+	Path string
+	// Name is the constant name, manifest field, or env var name.
+	Name string
+}
+
+// DefaultVersionStrategy reads a VERSION file.
+var DefaultVersionStrategy = VersionStrategy{Kind: VersionFromFile}
+
+// ParseVersionStrategy parses s. An empty string means DefaultVersionStrategy.
+func ParseVersionStrategy(s string) (VersionStrategy, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DefaultVersionStrategy, nil
+	}
+	parts := strings.Split(s, ":")
+	vs := VersionStrategy{Kind: VersionStrategyKind(parts[0])}
+	args := parts[1:]
+	switch vs.Kind {
+	case VersionFromFile, VersionFromGitTag:
+		if len(args) != 0 {
+			return vs, fmt.Errorf("version strategy %q takes no arguments", vs.Kind)
+		}
+	case VersionFromGoConst, VersionFromManifest:
+		if len(args) < 1 || len(args) > 2 || args[0] == "" {
+			return vs, fmt.Errorf("version strategy %q must be in the form %s:<file>[:<name>]", s, vs.Kind)
+		}
+		vs.Path = args[0]
+		if len(args) == 2 {
+			vs.Name = args[1]
+		}
+	case VersionFromEnv:
+		if len(args) != 1 || args[0] == "" {
+			return vs, fmt.Errorf("version strategy %q must be in the form env:<name>", s)
+		}
+		vs.Name = args[0]
+	default:
+		return vs, fmt.Errorf("unknown version strategy %q; must be one of file, git-tag, go-const, manifest, env", vs.Kind)
+	}
+	return vs, nil
+}
+
+func (vs VersionStrategy) String() string {
+	parts := []string{string(vs.Kind)}
+	if vs.Path != "" {
+		parts = append(parts, vs.Path)
+	}
+	if vs.Name != "" {
+		parts = append(parts, vs.Name)
+	}
+	return strings.Join(parts, ":")
+}
+
+// getCoreVersion reads the core version from dir using the strategy vs.
+func getCoreVersion(dir string, vs VersionStrategy) (*version.Version, error) {
+	var v *version.Version
+	var err error
+	switch vs.Kind {
+	case VersionFromFile, "":
+		return getCoreVersionFromVersionFile(dir)
+	case VersionFromGitTag:
+		v, err = getCoreVersionFromGitTag(dir)
+	case VersionFromGoConst:
+		v, err = getCoreVersionFromGoConst(dir, vs.Path, vs.Name)
+	case VersionFromManifest:
+		v, err = getCoreVersionFromManifest(dir, vs.Path, vs.Name)
+	case VersionFromEnv:
+		v, err = parseVersion(os.Getenv(vs.Name))
+	default:
+		err = fmt.Errorf("unknown version strategy %q", vs.Kind)
+	}
+	return v, maybeErr(err, "getting version using strategy %q", vs)
+}
+
+// describeVersionSource describes where getCoreVersion found v.
+func describeVersionSource(dir string, vs VersionStrategy, v *version.Version) string {
+	switch vs.Kind {
+	case VersionFromFile, "":
+		if v.Equal(defaultVersion) {
+			return "default (no VERSION file)"
+		}
+		f, err := getVersionFile(dir)
+		if err != nil {
+			return "VERSION file"
+		}
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			rel = f
+		}
+		return "version file " + filepath.ToSlash(rel)
+	case VersionFromGitTag:
+		return "latest git tag"
+	case VersionFromGoConst:
+		name := vs.Name
+		if name == "" {
+			name = "Version"
+		}
+		return fmt.Sprintf("Go constant %s in %s", name, vs.Path)
+	case VersionFromManifest:
+		if vs.Name == "" {
+			return fmt.Sprintf("version field in %s", vs.Path)
+		}
+		return fmt.Sprintf("field %s in %s", vs.Name, vs.Path)
+	case VersionFromEnv:
+		return "environment variable " + vs.Name
+	}
+	return string(vs.Kind)
+}
+
+func getCoreVersionFromGitTag(dir string) (*version.Version, error) {
+	out, err := gitOutput(dir, "tag", "--merged", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var latest *version.Version
+	var latestTag string
+	for _, tag := range strings.Fields(out) {
+		v, err := version.NewSemver(tag)
+		if err != nil || v.Metadata() != "" {
+			// Not every tag is a version.
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest, latestTag = v, tag
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no semver tags reachable from HEAD (is this a shallow clone?)")
+	}
+	count, err := gitOutput(dir, "rev-list", "--count", latestTag+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if count = strings.TrimSpace(count); count == "0" {
+		return latest, nil
+	}
+	// Append the commit count as a new prerelease identifier.
+	v := latest.Core().String()
+	if pre := latest.Prerelease(); pre != "" {
+		return parseVersion(fmt.Sprintf("%s-%s.%s", v, pre, count))
+	}
+	return parseVersion(fmt.Sprintf("%s-%s", v, count))
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func getCoreVersionFromGoConst(dir, file, name string) (*version.Version, error) {
+	if name == "" {
+		name = "Version"
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, file), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || (gd.Tok != token.CONST && gd.Tok != token.VAR) {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, n := range vs.Names {
+				if n.Name != name {
+					continue
+				}
+				if i >= len(vs.Values) {
+					return nil, fmt.Errorf("%s in %s has no value", name, file)
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return nil, fmt.Errorf("%s in %s is not a string literal", name, file)
+				}
+				s, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return nil, err
+				}
+				return parseVersion(s)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no constant or variable named %s in %s", name, file)
+}
+
+func getCoreVersionFromManifest(dir, file, field string) (*version.Version, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return nil, err
+	}
+	var s string
+	switch ext := filepath.Ext(file); ext {
+	case ".json":
+		if field == "" {
+			field = "version"
+		}
+		s, err = jsonStringField(data, field)
+	case ".toml":
+		if field == "" {
+			field = "package.version"
+		}
+		s, err = tomlStringField(data, field)
+	default:
+		return nil, fmt.Errorf("unsupported manifest type %q; must be .json or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s from %s: %w", field, file, err)
+	}
+	return parseVersion(s)
+}
+
+// jsonStringField returns the string at the dot-separated path field.
+func jsonStringField(data []byte, field string) (string, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	for _, key := range strings.Split(field, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("field not found")
+		}
+		if v, ok = m[key]; !ok {
+			return "", fmt.Errorf("field not found")
+		}
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field is not a string")
+	}
+	return s, nil
+}
+
+// tomlStringField returns the value of a basic string key in a TOML file.
+// The field is written as table.key, or just key for top-level keys.
+// This only understands the simple subset of TOML used for versions in
+// manifests like Cargo.toml, not the whole language.
+func tomlStringField(data []byte, field string) (string, error) {
+	table, key := "", field
+	if i := strings.LastIndex(field, "."); i != -1 {
+		table, key = field[:i], field[i+1:]
+	}
+	current := ""
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "[") {
+			current = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		if current != table {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(k) != key {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if i := strings.Index(v, "#"); i > 0 && strings.HasPrefix(v, `"`) {
+			// Drop trailing comments after the closing quote.
+			if end := strings.LastIndex(v[:i], `"`); end > 0 {
+				v = v[:end+1]
+			}
+		}
+		return strconv.Unquote(strings.TrimSpace(v))
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("field not found")
+}
@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package crt
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tmp "github.com/hashicorp/composite-action-framework-go/pkg/testhelpers/tmptest"
+)
+
+func TestParseVersionStrategy(t *testing.T) {
+
+	cases := []struct {
+		in      string
+		want    VersionStrategy
+		wantErr string
+	}{
+		{"", DefaultVersionStrategy, ""},
+		{"file", VersionStrategy{Kind: VersionFromFile}, ""},
+		{"git-tag", VersionStrategy{Kind: VersionFromGitTag}, ""},
+		{"go-const:version/version.go", VersionStrategy{Kind: VersionFromGoConst, Path: "version/version.go"}, ""},
+		{"manifest:Cargo.toml:workspace.package.version", VersionStrategy{Kind: VersionFromManifest, Path: "Cargo.toml", Name: "workspace.package.version"}, ""},
+		{"env:MY_VERSION", VersionStrategy{Kind: VersionFromEnv, Name: "MY_VERSION"}, ""},
+		{"git-tag:blah", VersionStrategy{}, "takes no arguments"},
+		{"go-const", VersionStrategy{}, "must be in the form"},
+		{"env:", VersionStrategy{}, "must be in the form"},
+		{"magic", VersionStrategy{}, "unknown version strategy"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.in, func(t *testing.T) {
+			got, err := ParseVersionStrategy(c.in)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("got error %v; want error containing %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Error(diff)
+			}
+			if s := got.String(); c.in != "" && s != c.in {
+				t.Errorf("got String() %q; want %q", s, c.in)
+			}
+		})
+	}
+}
+
+func TestGetCoreVersion_ok(t *testing.T) {
+
+	const goFile = `package version
+
+const (
+	Version = "1.4.0"
+	Other   = "9.9.9"
+)
+
+var Prerelease = "1.5.0-beta1"
+`
+	const cargo = `[package]
+name = "lockbox"
+version = "0.3.1" # bump me
+
+[dependencies]
+version = "1.0.0"
+`
+
+	cases := []struct {
+		desc     string
+		strategy string
+		env      map[string]string
+		files    []versionFile
+		want     string
+	}{
+		{"file", "file", nil, []versionFile{{".release/VERSION", "1.2.3"}}, "1.2.3"},
+		{"go const", "go-const:version/version.go", nil, []versionFile{{"version/version.go", goFile}}, "1.4.0"},
+		{"go var", "go-const:version/version.go:Prerelease", nil, []versionFile{{"version/version.go", goFile}}, "1.5.0-beta1"},
+		{"package.json", "manifest:package.json", nil, []versionFile{{"package.json", `{"name": "x", "version": "2.0.1"}`}}, "2.0.1"},
+		{"nested json", "manifest:meta.json:release.version", nil, []versionFile{{"meta.json", `{"release": {"version": "2.0.2"}}`}}, "2.0.2"},
+		{"Cargo.toml", "manifest:Cargo.toml", nil, []versionFile{{"Cargo.toml", cargo}}, "0.3.1"},
+		{"env", "env:LOCKBOX_VERSION", map[string]string{"LOCKBOX_VERSION": "3.1.4"}, nil, "3.1.4"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+			dir := writeTmpFileTree(t, c.files)
+			vs, err := ParseVersionStrategy(c.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := getCoreVersion(dir, vs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != c.want {
+				t.Errorf("got %q; want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestGetCoreVersion_err(t *testing.T) {
+
+	cases := []struct {
+		desc     string
+		strategy string
+		files    []versionFile
+		want     string
+	}{
+		{"missing const", "go-const:v.go:Nope", []versionFile{{"v.go", "package v\nconst Version = \"1.0.0\""}}, "no constant or variable named Nope"},
+		{"non-literal const", "go-const:v.go", []versionFile{{"v.go", "package v\nconst Version = base + \".1\""}}, "not a string literal"},
+		{"missing field", "manifest:package.json", []versionFile{{"package.json", `{"name": "x"}`}}, "reading version from package.json: field not found"},
+		{"unknown manifest", "manifest:setup.cfg", []versionFile{{"setup.cfg", ""}}, "unsupported manifest type"},
+		{"empty env", "env:UNSET_VERSION_FOR_TEST", nil, `invalid version ""`},
+		{"metadata", "manifest:package.json", []versionFile{{"package.json", `{"version": "1.0.0+ent"}`}}, "contains metadata"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeTmpFileTree(t, c.files)
+			vs, err := ParseVersionStrategy(c.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = getCoreVersion(dir, vs)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("got error %v; want error containing %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestGetCoreVersionFromGitTag(t *testing.T) {
+
+	cases := []struct {
+		desc string
+		// script is a list of "commit" or "tag <name>" actions.
+		script []string
+		want   string
+	}{
+		{"tagged head", []string{"commit", "tag v1.2.3"}, "1.2.3"},
+		{"commits since", []string{"commit", "tag v1.2.3", "commit", "commit"}, "1.2.3-2"},
+		{"prerelease commits since", []string{"commit", "tag v1.3.0-rc1", "commit"}, "1.3.0-rc1.1"},
+		{"highest wins", []string{"commit", "tag v1.10.0", "commit", "tag v1.9.0"}, "1.10.0-1"},
+		{"non-version tags ignored", []string{"commit", "tag v0.1.0", "commit", "tag nightly"}, "0.1.0-1"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.desc, func(t *testing.T) {
+			dir := tmp.Dir(t)
+			runGit(t, dir, "init", "-q")
+			for i, step := range c.script {
+				if step == "commit" {
+					runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "commit "+string(rune('a'+i)))
+					continue
+				}
+				runGit(t, dir, "tag", strings.TrimPrefix(step, "tag "))
+			}
+			got, err := getCoreVersion(dir, VersionStrategy{Kind: VersionFromGitTag})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != c.want {
+				t.Errorf("got %q; want %q", got, c.want)
+			}
+		})
+	}
+
+	t.Run("no tags", func(t *testing.T) {
+		dir := tmp.Dir(t)
+		runGit(t, dir, "init", "-q")
+		runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+		_, err := getCoreVersion(dir, VersionStrategy{Kind: VersionFromGitTag})
+		if err == nil || !strings.Contains(err.Error(), "no semver tags") {
+			t.Fatalf("got error %v; want no semver tags error", err)
+		}
+	})
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@test.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+}